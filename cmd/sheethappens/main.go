@@ -1,27 +1,209 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/utils"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
 )
 
 var (
 	branch = flag.String("b", "", "Specific branch name (optional)")
 	days   = flag.Int("t", 0, "Number of days to look back for commits (0 = all history)")
-	dir    = flag.String("d", ".", "Git repository directory (default: current directory)")
+	desc   = flag.String("m", "", "Description sent along with the uploaded sheet (optional)")
+	draft  = flag.Bool("draft", false, "Upload the sheet as a draft to edit in the dashboard before submitting it for review")
+
+	idle        = flag.Duration("idle", report.DefaultIdleThreshold, "Gap between two commits that ends a work session")
+	firstCommit = flag.Duration("first-commit", report.DefaultFirstCommit, "Time credited to the first commit of a work session")
+
+	dirs           listFlag
+	remotes        listFlag
+	depth          = flag.Int("depth", 0, "Commits to fetch per branch of a --repo URL (0 = full history)")
+	authors        listFlag
+	excludeAuthors listFlag
+	timeKeys       listFlag
+	ticketPatterns listFlag
+	remoteBranches = flag.Bool("remote-branches", false, "Also include commits only on remote-tracking branches (refs/remotes/*)")
+	tags           = flag.Bool("tags", false, "Also include commits only reachable from tags")
+	refs           listFlag
+	excludeRefs    listFlag
+	hashCol        = flag.Bool("hash", false, "Add the full commit hash column")
+	shortHashCol   = flag.Bool("short-hash", false, "Add the abbreviated commit hash column")
+	branchCol      = flag.Bool("show-branch", false, "Add the branch or tag that introduced each commit")
+	paths          listFlag
+	diffStats      = flag.Bool("diff-stats", false, "Add lines added and removed, files changed and top-level directories per commit")
+	ticketSummary  = flag.Bool("ticket-summary", false, "Also write the hours per ticket to {sheet}_tickets.csv")
+	notesRef       = flag.String("notes-ref", report.DefaultNotesRef, "Git notes read for tracked time")
+	excludeMsgs    listFlag
+	merges         = flag.String("merges", report.MergesKeep, "Merge commits: keep, drop, or collapse into the commits they merge")
+	reverts        = flag.String("reverts", report.RevertsKeep, "Reverts: keep, pair them with the reverted commit, or drop both")
+	excludeBots    = flag.Bool("exclude-bots", false, "Drop commits by dependency updaters and other bots")
+	showDropped    = flag.Bool("show-dropped", false, "Keep dropped commits as rows without hours and say why in a Dropped column")
+	allAuthors     = flag.Bool("all-authors", false, "Include every author instead of defaulting to your git user.email")
+	perAuthor      = flag.Bool("per-author", false, "Write one sheet per author (every author unless --author is given)")
+	stream         = flag.Bool("stream", false, "Estimate the CSV session by session as commits are walked, for very large repositories")
+
+	format = flag.String("format", report.FormatCSV, "Output format: "+strings.Join(report.Formats, ", "))
+
+	since     = flag.String("since", "", "Only include commits from this date on, eg: 2024-03-01")
+	until     = flag.String("until", "", "Only include commits up to this date, inclusive")
+	period    = flag.String("period", "", "Calendar period instead of --since/--until: "+strings.Join(report.Periods, ", "))
+	weekStart = flag.String("week-start", getOptEnv("SHEET_HAPPENS_WEEK_START", "monday"), "First day of the week for --period")
+	timezone  = flag.String("tz", getOptEnv("SHEET_HAPPENS_TZ", "Local"), "Timezone dates and periods are read in, eg: Asia/Kathmandu")
 )
 
+// listFlag collects a flag that may be repeated, eg: --author a --author b
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func init() {
+	flag.Var(&dirs, "d", "Git repository directory, or a directory to scan for repositories, repeatable (default: current directory)")
+	flag.Var(&remotes, "repo", "Repository URL to clone into memory instead of a local directory, repeatable (file://, ssh or https)")
+	flag.Var(&refs, "ref", "Also include commits reachable from refs matching this glob, repeatable, eg: 'refs/pull/*'")
+	flag.Var(&excludeRefs, "exclude-ref", "Skip refs matching this glob, repeatable, eg: 'origin/dependabot/*'")
+	flag.Var(&paths, "paths", "Only include commits touching this path of the repository, repeatable, eg: services/api")
+	flag.Var(&authors, "author", "Only include commits whose author name or email contains this, repeatable (default: git config user.email)")
+	flag.Var(&excludeAuthors, "exclude-author", "Drop commits whose author name or email contains this, repeatable")
+	flag.Var(&excludeMsgs, "exclude-message", "Drop commits whose message matches this regexp, repeatable, eg: '^chore\\(deps\\)'")
+	flag.Var(&ticketPatterns, "ticket-pattern", "Regexp matching ticket keys in commit messages and branch names, repeatable (default: PROJ-123 and #456)")
+	flag.Var(&timeKeys, "time-key", "Trailer or note key holding tracked time, repeatable (default: "+report.DefaultTimeKey+")")
 	flag.Parse()
 }
 
 func main() {
-	if _, err := os.Stat(*dir); os.IsNotExist(err) {
-		log.Fatalf("Provided directory does not exist: %s", *dir)
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			log.Fatalf("Provided directory does not exist: %s", dir)
+		}
+	}
+
+	if !slices.Contains(report.Formats, *format) {
+		log.Fatalf("Unknown format %q, expected one of: %s", *format, strings.Join(report.Formats, ", "))
+	}
+	if *stream && (*format != report.FormatCSV || *perAuthor || *ticketSummary) {
+		log.Fatal("--stream only writes a single CSV sheet, it can't be combined with --format, --per-author or --ticket-summary")
+	}
+
+	commitRange, err := resolveRange()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := report.Options{
+		Folders: dirs,
+		Remotes: remotes,
+		RemoteAuth: report.RemoteAuth{
+			Username:         os.Getenv("SHEET_HAPPENS_GIT_USERNAME"),
+			Password:         os.Getenv("SHEET_HAPPENS_GIT_PASSWORD"),
+			SSHKeyPath:       os.Getenv("SHEET_HAPPENS_SSH_KEY"),
+			SSHKeyPassphrase: os.Getenv("SHEET_HAPPENS_SSH_KEY_PASSPHRASE"),
+		},
+		CloneDepth:  *depth,
+		Branch:      *branch,
+		SinceDays:   *days,
+		Range:       commitRange,
+		Period:      *period,
+		Description: *desc,
+
+		RemoteBranches: *remoteBranches,
+		Tags:           *tags,
+		Refs:           refs,
+		ExcludeRefs:    excludeRefs,
+
+		Estimate: &report.EstimateOptions{
+			IdleThreshold: *idle,
+			FirstCommit:   *firstCommit,
+		},
+		TimeKeys:        timeKeys,
+		NotesRef:        *notesRef,
+		TicketPatterns:  ticketPatterns,
+		HashColumn:      *hashCol,
+		ShortHashColumn: *shortHashCol,
+		BranchColumn:    *branchCol,
+		DiffStats:       *diffStats,
+		Paths:           paths,
+
+		Merges:          *merges,
+		Reverts:         *reverts,
+		ExcludeMessages: excludeMsgs,
+		ExcludeBots:     *excludeBots,
+		ShowDropped:     *showDropped,
+
+		Authors:        authors,
+		ExcludeAuthors: excludeAuthors,
+		AllAuthors:     *allAuthors || *perAuthor,
+	}
+
+	if *stream {
+		if err := streamOutput(opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	rep, err := report.Generate(context.Background(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Commits from", rep.Range)
+
+	filename := sheetFilename(rep)
+
+	if !*perAuthor {
+		if err := output(rep, filename); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	services.GenerateCSV(*dir, *branch, *days)
+	for _, authorReport := range rep.ByAuthor() {
+		name := strings.TrimSuffix(filename, "_log") + "_" + report.AuthorSlug(authorReport.Records[0].AuthorEmail) + "_log"
+		if err := output(authorReport, name); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// sheetFilename names the sheet after its repository, without extension
+func sheetFilename(rep *report.Report) string {
+	repoName := rep.Repo
+	if len(rep.Repos) > 1 {
+		repoName = "multi-repo"
+	}
+	return fmt.Sprintf("%d_%s_%s_log", time.Now().Unix(), utils.RepoSlug(repoName), utils.Generate4DigitCode())
+}
+
+// resolveRange builds the commit range from --period or --since/--until
+func resolveRange() (report.DateRange, error) {
+	return report.RangeQuery{
+		SinceDays: *days,
+		Since:     *since,
+		Until:     *until,
+		Period:    *period,
+		WeekStart: *weekStart,
+		Timezone:  *timezone,
+	}.Resolve(time.Now())
+}
+
+// getOptEnv retrieves the value of the environment variable or returns a default value if not set
+func getOptEnv(varName, defaultValue string) string {
+	if value, ok := os.LookupEnv(varName); ok {
+		return value
+	}
+	return defaultValue
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// output uploads the report when BACKEND_URL is set, otherwise writes it to
+// `filename` in the current directory. The server takes CSV and JSON, other
+// formats are uploaded as CSV and also written locally.
+func output(rep *report.Report, filename string) error {
+	if backendURL := os.Getenv("BACKEND_URL"); backendURL != "" {
+		token, err := report.LoadAPIToken()
+		if err != nil {
+			return err
+		}
+
+		uploadFormat := report.FormatCSV
+		if *format == report.FormatJSON {
+			uploadFormat = report.FormatJSON
+		}
+
+		var buf bytes.Buffer
+		if err := rep.Write(&buf, uploadFormat); err != nil {
+			return fmt.Errorf("error writing sheet to buffer: %w", err)
+		}
+
+		uploader := &report.HTTPUploader{BackendURL: backendURL, Token: token, Draft: *draft}
+		id, err := uploader.Upload(context.Background(), filename+"."+uploadFormat, &buf, rep.Meta())
+		if err != nil {
+			return fmt.Errorf("failed to upload CSV: %w", err)
+		}
+		fmt.Println("Get your csv from here: ", uploader.URL(id))
+
+		if *format == uploadFormat {
+			return writeSummary(rep, filename)
+		}
+	}
+
+	name := filename + "." + report.FormatExtension(*format)
+	if err := writeFile(name, func(f *os.File) error { return rep.Write(f, *format) }); err != nil {
+		return err
+	}
+
+	return writeSummary(rep, filename)
+}
+
+// streamOutput writes the sheet with --stream as commits are walked. The
+// repositories are only named once the walk is done, so the sheet goes to a
+// temporary file first and is uploaded from disk when BACKEND_URL is set.
+func streamOutput(opts report.Options) error {
+	tmp, err := os.CreateTemp(".", ".sheethappens-*.csv")
+	if err != nil {
+		return fmt.Errorf("error creating sheet: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rep, err := report.StreamCSV(context.Background(), opts, tmp)
+	if err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing sheet: %w", err)
+	}
+	fmt.Println("Commits from", rep.Range)
+
+	filename := sheetFilename(rep)
+	name := filename + "." + report.FormatCSV
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("error creating %s: %w", name, err)
+	}
+	fmt.Println("Sheet generated:", name)
+
+	backendURL := os.Getenv("BACKEND_URL")
+	if backendURL == "" {
+		return nil
+	}
+	token, err := report.LoadAPIToken()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	uploader := &report.HTTPUploader{BackendURL: backendURL, Token: token, Draft: *draft}
+	id, err := uploader.Upload(context.Background(), name, file, rep.Meta())
+	if err != nil {
+		return fmt.Errorf("failed to upload CSV: %w", err)
+	}
+	fmt.Println("Get your csv from here: ", uploader.URL(id))
+	return nil
+}
+
+// writeSummary writes the hours per ticket with --ticket-summary, the
+// summary is not a sheet so it is always written locally
+func writeSummary(rep *report.Report, filename string) error {
+	if !*ticketSummary {
+		return nil
+	}
+
+	rows := report.TicketSummary(rep.Rows())
+	return writeFile(filename+"_tickets.csv", func(f *os.File) error {
+		return report.WriteSheet(f, report.FormatCSV, rows, rep.Meta())
+	})
+}
+
+func writeFile(name string, write func(f *os.File) error) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+
+	fmt.Println("Sheet generated:", name)
+	return file.Close()
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+)
+
+const sessionContextKey = "session"
+
+// RequireSession rejects requests without a valid session cookie. Login
+// sessions only travel in the HttpOnly cookie, personal API tokens are
+// refused as they only upload sheets, see RequireUploader.
+func RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return requireSession(next, false)
+}
+
+// RequireUploader is RequireSession that also accepts a bearer personal API
+// token, for the sheet upload the CLI does
+func RequireUploader(next echo.HandlerFunc) echo.HandlerFunc {
+	return requireSession(next, true)
+}
+
+func requireSession(next echo.HandlerFunc, allowTokens bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := bearerToken(c)
+
+		if IsAPIToken(token) {
+			if !allowTokens {
+				return echo.NewHTTPError(http.StatusForbidden, "API tokens can only upload sheets")
+			}
+			record, err := ParseAPIToken(c.Request().Context(), token)
+			if errors.Is(err, ErrInvalidToken) {
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+			if err != nil {
+				return err
+			}
+
+			c.Set(sessionContextKey, &Session{
+				Email:   record.Owner,
+				Name:    DisplayName(record.Owner),
+				TokenID: record.ID,
+			})
+			return next(c)
+		}
+
+		cookie, err := c.Cookie(SessionCookie)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "login required")
+		}
+
+		session, err := ParseSession(cookie.Value)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "login required")
+		}
+
+		if !trustedOrigin(c.Request()) {
+			return echo.NewHTTPError(http.StatusForbidden, "cross-site request refused")
+		}
+
+		c.Set(sessionContextKey, session)
+		return next(c)
+	}
+}
+
+// RequireAdmin rejects sessions whose email is not listed in ADMIN_EMAILS,
+// it must run after RequireSession
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		session := CurrentSession(c)
+		if session == nil || session.TokenID != "" || !slices.ContainsFunc(config.Env.AdminEmails, func(email string) bool {
+			return strings.EqualFold(email, session.Email)
+		}) {
+			return echo.NewHTTPError(http.StatusForbidden, "admin access required")
+		}
+
+		return next(c)
+	}
+}
+
+// CurrentSession returns the session set by RequireSession
+func CurrentSession(c echo.Context) *Session {
+	session, _ := c.Get(sessionContextKey).(*Session)
+	return session
+}
+
+// trustedOrigin reports whether a cookie authenticated request may change
+// anything, browsers send an Origin with every cross-origin POST so one that
+// is neither this host nor a FRONTEND_HOST comes from another site
+func trustedOrigin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	origin := r.Header.Get(echo.HeaderOrigin)
+	if origin == "" || slices.Contains(config.Env.FrontOrigins, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func bearerToken(c echo.Context) string {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedOrigin(t *testing.T) {
+	setupAuth(t)
+
+	tests := map[string]struct {
+		method string
+		origin string
+		want   bool
+	}{
+		"read":                 {method: http.MethodGet, origin: "https://evil.example.com", want: true},
+		"no origin":            {method: http.MethodPost, want: true},
+		"frontend":             {method: http.MethodPost, origin: "https://sheets.webpoint.io", want: true},
+		"second frontend":      {method: http.MethodPut, origin: "http://localhost:3000", want: true},
+		"same host":            {method: http.MethodDelete, origin: "https://api.webpoint.io", want: true},
+		"other site":           {method: http.MethodPost, origin: "https://evil.example.com"},
+		"frontend other port":  {method: http.MethodPost, origin: "http://localhost:3001"},
+		"frontend other proto": {method: http.MethodPatch, origin: "http://sheets.webpoint.io"},
+		"null origin":          {method: http.MethodPost, origin: "null"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "https://api.webpoint.io/csv/1", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := trustedOrigin(r); got != tt.want {
+				t.Errorf("trustedOrigin(%s from %q) = %v, want %v", tt.method, tt.origin, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+	"github.com/webpointsolutions/sheet-happens/internal/utils"
+)
+
+const (
+	codeDigits      = 6
+	codeTTL         = 10 * time.Minute
+	codeMaxAttempts = 5
+	codeResendAfter = 30 * time.Second
+
+	// guesses at any code of one email, resending a code doesn't reset them
+	codeMaxGuesses = 10
+	guessWindow    = time.Hour
+)
+
+var (
+	ErrInvalidEmail    = errors.New("invalid email provided")
+	ErrInvalidCode     = errors.New("invalid or expired code")
+	ErrTooManyAttempts = errors.New("too many attempts, request a new code")
+	ErrResendTooSoon   = errors.New("a code was sent recently, try again shortly")
+	ErrLoginLocked     = errors.New("too many failed logins, try again later")
+)
+
+// loginCode is stored through services.Store so any replica can verify it
+type loginCode struct {
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func codeKey(email string) string {
+	return "auth/codes/" + hashValue(email) + ".json"
+}
+
+// every guess at a code claims one key below its attempts prefix with
+// Store.Create, so parallel guesses on any replica can't share an attempt
+func attemptsPrefix(email string) string {
+	return "auth/attempts/" + hashValue(email) + "/"
+}
+
+func codeAttemptsPrefix(email string, code *loginCode) string {
+	return fmt.Sprintf("%s%d/", attemptsPrefix(email), code.CreatedAt.UnixNano())
+}
+
+// guesses at the codes of an email are claimed like attempts, below the
+// prefix of the hour they are made in
+func guessesPrefix(email string) string {
+	return "auth/guesses/" + hashValue(email) + "/"
+}
+
+func guessWindowPrefix(email string, now time.Time) string {
+	return fmt.Sprintf("%s%d/", guessesPrefix(email), now.Truncate(guessWindow).Unix())
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeEmail lower cases `email` and checks it belongs to the login domain
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// a bare address only, not "Name <address>"
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	local, domain, _ := strings.Cut(email, "@")
+	if local == "" || domain != config.Env.LoginDomain {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// SendLoginCode emails a one-time code to `email`, replacing any earlier one.
+// No code is sent while the email is locked out by failed guesses.
+func SendLoginCode(ctx context.Context, email string) error {
+	key := codeKey(email)
+
+	locked, err := loginLocked(ctx, email)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrLoginLocked
+	}
+
+	if existing, err := readCode(ctx, key); err == nil && time.Since(existing.CreatedAt) < codeResendAfter {
+		return ErrResendTooSoon
+	}
+
+	code, err := utils.GenerateOTP(codeDigits)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(loginCode{
+		Hash:      hashValue(code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(codeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := services.Store.Put(ctx, key, data); err != nil {
+		return err
+	}
+	// attempts at earlier codes, the guesses of this hour stay
+	deletePrefix(ctx, attemptsPrefix(email))
+	deleteStaleGuesses(ctx, email)
+
+	err = services.SendEmailWithAttachment(services.EmailRequestParams{
+		To:            email,
+		Subject:       "Your Sheet Happens login code",
+		EmailTemplate: "login",
+		TemplateParams: map[string]any{
+			"Name":      DisplayName(email),
+			"Code":      code,
+			"ExpiresIn": "10 minutes",
+		},
+	})
+	if err != nil {
+		// lets the user ask again right away
+		_ = services.Store.Delete(ctx, key)
+		return err
+	}
+
+	return nil
+}
+
+// VerifyLoginCode consumes the code sent to `email`. Every guess claims an
+// attempt before it is compared, the code is removed once used or exhausted.
+// Guesses also count against the hourly budget of the email, once it is spent
+// the email is locked out until the next hour.
+func VerifyLoginCode(ctx context.Context, email, code string) error {
+	key := codeKey(email)
+
+	stored, err := readCode(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		_ = services.Store.Delete(ctx, key)
+		return ErrInvalidCode
+	}
+
+	if _, err := claimAttempt(ctx, guessWindowPrefix(email, time.Now()), codeMaxGuesses); err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			_ = services.Store.Delete(ctx, key)
+			return ErrLoginLocked
+		}
+		return err
+	}
+
+	attempts := codeAttemptsPrefix(email, stored)
+	attempt, err := claimAttempt(ctx, attempts, codeMaxAttempts)
+	if errors.Is(err, ErrTooManyAttempts) {
+		_ = services.Store.Delete(ctx, key)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hashValue(strings.TrimSpace(code)))) == 1 {
+		// a code logs in once, even when sent twice at the same time
+		if err := services.Store.Create(ctx, attempts+"used", []byte("1")); err != nil {
+			if errors.Is(err, storage.ErrExists) {
+				return ErrInvalidCode
+			}
+			return err
+		}
+		return services.Store.Delete(ctx, key)
+	}
+
+	if attempt >= codeMaxAttempts {
+		_ = services.Store.Delete(ctx, key)
+		return ErrTooManyAttempts
+	}
+	return ErrInvalidCode
+}
+
+// claimAttempt takes the next free one of `limit` attempts below `prefix`,
+// ErrTooManyAttempts once all of them are taken
+func claimAttempt(ctx context.Context, prefix string, limit int) (int, error) {
+	for n := 1; n <= limit; n++ {
+		err := services.Store.Create(ctx, prefix+strconv.Itoa(n), []byte("1"))
+		if err == nil {
+			return n, nil
+		}
+		if !errors.Is(err, storage.ErrExists) {
+			return 0, err
+		}
+	}
+	return 0, ErrTooManyAttempts
+}
+
+// loginLocked reports whether the guesses of this hour at the codes of
+// `email` are spent
+func loginLocked(ctx context.Context, email string) (bool, error) {
+	guesses, err := services.Store.List(ctx, guessWindowPrefix(email, time.Now()))
+	if err != nil {
+		return false, err
+	}
+	return len(guesses) >= codeMaxGuesses, nil
+}
+
+// deleteStaleGuesses removes the guesses of `email` from earlier hours
+func deleteStaleGuesses(ctx context.Context, email string) {
+	current := guessWindowPrefix(email, time.Now())
+
+	objects, err := services.Store.List(ctx, guessesPrefix(email))
+	if err != nil {
+		return
+	}
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, current) {
+			_ = services.Store.Delete(ctx, obj.Key)
+		}
+	}
+}
+
+// deletePrefix removes what is stored below `prefix` as far as it can
+func deletePrefix(ctx context.Context, prefix string) {
+	objects, err := services.Store.List(ctx, prefix)
+	if err != nil {
+		return
+	}
+	for _, obj := range objects {
+		_ = services.Store.Delete(ctx, obj.Key)
+	}
+}
+
+func readCode(ctx context.Context, key string) (*loginCode, error) {
+	data, err := services.Store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var code loginCode
+	if err := json.Unmarshal(data, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
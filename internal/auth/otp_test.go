@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+const testEmail = "jane.doe@webpoint.io"
+
+// setupAuth loads a test environment and points services.Store at a local
+// backend in a temporary directory
+func setupAuth(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{"SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_HOST", "SMTP_PORT"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("FRONTEND_HOST", "https://sheets.webpoint.io,http://localhost:3000")
+	t.Setenv("SESSION_SECRET", strings.Repeat("s", 32))
+	t.Setenv("LOGIN_DOMAIN", "webpoint.io")
+
+	previousEnv := config.Env
+	config.LoadEnv()
+	t.Cleanup(func() { config.Env = previousEnv })
+
+	s, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousStore := services.Store
+	services.Store = s
+	t.Cleanup(func() { services.Store = previousStore })
+}
+
+// putCode stores `code` as the login code of testEmail, created at `createdAt`
+func putCode(t *testing.T, code string, createdAt time.Time) {
+	t.Helper()
+
+	data, err := json.Marshal(loginCode{
+		Hash:      hashValue(code),
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(codeTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := services.Store.Put(context.Background(), codeKey(testEmail), data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyLoginCode(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		createdAt time.Time
+		claimed   int      // attempts already claimed, eg: by other replicas
+		guesses   []string // wrong guesses before the right code
+		want      error
+	}{
+		"right code":       {createdAt: time.Now()},
+		"expired code":     {createdAt: time.Now().Add(-codeTTL - time.Second), want: ErrInvalidCode},
+		"after 4 guesses":  {createdAt: time.Now(), guesses: []string{"1", "2", "3", "4"}},
+		"after 5 guesses":  {createdAt: time.Now(), guesses: []string{"1", "2", "3", "4", "5"}, want: ErrInvalidCode},
+		"attempts taken":   {createdAt: time.Now(), claimed: codeMaxAttempts, want: ErrTooManyAttempts},
+		"one attempt left": {createdAt: time.Now(), claimed: codeMaxAttempts - 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setupAuth(t)
+			putCode(t, "123456", tt.createdAt.Round(0))
+
+			stored, err := readCode(ctx, codeKey(testEmail))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n := 1; n <= tt.claimed; n++ {
+				key := codeAttemptsPrefix(testEmail, stored) + strconv.Itoa(n)
+				if err := services.Store.Create(ctx, key, []byte("1")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, guess := range tt.guesses {
+				err := VerifyLoginCode(ctx, testEmail, guess)
+				want := ErrInvalidCode
+				if i == codeMaxAttempts-1 {
+					want = ErrTooManyAttempts
+				}
+				if !errors.Is(err, want) {
+					t.Fatalf("guess %d: got %v, want %v", i+1, err, want)
+				}
+			}
+
+			if err := VerifyLoginCode(ctx, testEmail, " 123456 "); !errors.Is(err, tt.want) {
+				t.Fatalf("right code: got %v, want %v", err, tt.want)
+			}
+
+			// a code logs in once and a refused code is gone
+			if err := VerifyLoginCode(ctx, testEmail, "123456"); !errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("code used again: got %v, want ErrInvalidCode", err)
+			}
+		})
+	}
+}
+
+func TestSendLoginCodeResend(t *testing.T) {
+	ctx := context.Background()
+	setupAuth(t)
+
+	putCode(t, "123456", time.Now().Add(-codeResendAfter/2))
+	if err := SendLoginCode(ctx, testEmail); !errors.Is(err, ErrResendTooSoon) {
+		t.Fatalf("got %v, want ErrResendTooSoon", err)
+	}
+
+	// the earlier code still works
+	if err := VerifyLoginCode(ctx, testEmail, "123456"); err != nil {
+		t.Fatalf("earlier code: %v", err)
+	}
+}
+
+func TestLoginLockedAfterGuesses(t *testing.T) {
+	ctx := context.Background()
+	setupAuth(t)
+
+	// resent codes share the guesses of the hour
+	for n := range codeMaxGuesses / codeMaxAttempts {
+		putCode(t, "123456", time.Now().Add(time.Duration(-n)*time.Minute).Round(0))
+		for range codeMaxAttempts {
+			if err := VerifyLoginCode(ctx, testEmail, "000000"); err == nil {
+				t.Fatal("wrong code accepted")
+			}
+		}
+	}
+
+	if err := SendLoginCode(ctx, testEmail); !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("send: got %v, want ErrLoginLocked", err)
+	}
+
+	putCode(t, "123456", time.Now().Round(0))
+	if err := VerifyLoginCode(ctx, testEmail, "123456"); !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("right code: got %v, want ErrLoginLocked", err)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	setupAuth(t)
+
+	tests := map[string]string{
+		" Jane.Doe@Webpoint.io ":          "jane.doe@webpoint.io",
+		"jane@example.com":                "",
+		"Jane <jane@webpoint.io>":         "",
+		"@webpoint.io":                    "",
+		"jane@webpoint.io.example.com":    "",
+		"jane@webpoint.io, x@webpoint.io": "",
+	}
+	for email, want := range tests {
+		got, err := NormalizeEmail(email)
+		if want == "" {
+			if !errors.Is(err, ErrInvalidEmail) {
+				t.Errorf("NormalizeEmail(%q) = %q, %v, want ErrInvalidEmail", email, got, err)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("NormalizeEmail(%q) = %q, %v, want %q", email, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+)
+
+const (
+	SessionCookie = "session"
+	SessionTTL    = 7 * 24 * time.Hour
+)
+
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+type Session struct {
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// set when the request was authenticated with a personal API token
+	TokenID string `json:"token_id,omitempty"`
+}
+
+// IssueSession returns a signed token for `email`, eg: {base64 payload}.{base64 hmac}
+func IssueSession(email string) (string, Session, error) {
+	session := Session{
+		Email:     email,
+		Name:      DisplayName(email),
+		ExpiresAt: time.Now().Add(SessionTTL),
+	}
+
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return "", Session{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded), session, nil
+}
+
+// ParseSession verifies the signature and expiry of a session token
+func ParseSession(token string) (*Session, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, ErrInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	return &session, nil
+}
+
+func sign(value string) string {
+	mac := hmac.New(sha256.New, []byte(config.Env.SessionSecret))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DisplayName turns `john.doe@webpoint.io` into `John Doe`
+func DisplayName(email string) string {
+	name, _, _ := strings.Cut(email, "@")
+
+	capitalize := func(s string) string {
+		if len(s) == 0 {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+	}
+
+	if !strings.Contains(name, ".") {
+		return capitalize(name)
+	}
+
+	names := strings.Split(name, ".")
+	return fmt.Sprintf("%s %s", capitalize(names[0]), capitalize(names[1]))
+}
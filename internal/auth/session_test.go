@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+)
+
+func TestParseSession(t *testing.T) {
+	setupAuth(t)
+
+	token, issued, err := IssueSession(testEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := ParseSession(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Email != testEmail || session.Name != "Jane Doe" || !session.ExpiresAt.Equal(issued.ExpiresAt) {
+		t.Errorf("ParseSession() = %+v, want %+v", session, issued)
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	// signs a payload with the current secret, as a forger holding it could
+	signed := func(session Session) string {
+		payload, err := json.Marshal(session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded := base64.RawURLEncoding.EncodeToString(payload)
+		return encoded + "." + sign(encoded)
+	}
+	forged := func(session Session) string {
+		payload, err := json.Marshal(session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(payload) + "." + signature
+	}
+
+	tests := map[string]string{
+		"no signature":     encoded,
+		"empty signature":  encoded + ".",
+		"other signature":  encoded + "." + strings.Repeat("A", len(signature)),
+		"changed email":    forged(Session{Email: "boss@webpoint.io", ExpiresAt: issued.ExpiresAt}),
+		"extended expiry":  forged(Session{Email: testEmail, ExpiresAt: issued.ExpiresAt.Add(time.Hour)}),
+		"expired":          signed(Session{Email: testEmail, ExpiresAt: time.Now().Add(-time.Second)}),
+		"invalid payload":  "bm90IGpzb24." + sign("bm90IGpzb24"),
+		"invalid encoding": "!!!." + sign("!!!"),
+		"empty":            "",
+	}
+	for name, token := range tests {
+		if _, err := ParseSession(token); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("%s: got %v, want ErrInvalidSession", name, err)
+		}
+	}
+
+	// a session signed with another secret
+	config.Env.SessionSecret = strings.Repeat("x", 32)
+	if _, err := ParseSession(token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("other secret: got %v, want ErrInvalidSession", err)
+	}
+}
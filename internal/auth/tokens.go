@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+// every personal API token starts with this, eg: sh_3q2-7wEjmU...
+const tokenPrefix = "sh_"
+
+var (
+	ErrInvalidToken  = errors.New("invalid or revoked token")
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+// APIToken is stored under the hash of the token, the token itself is only
+// shown once when it is created
+type APIToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func tokenKey(token string) string {
+	return "auth/tokens/" + hashValue(token) + ".json"
+}
+
+// CreateAPIToken mints a token for `owner` and returns it with its record
+func CreateAPIToken(ctx context.Context, owner, name string) (string, *APIToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+
+	record := &APIToken{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Owner:     owner,
+		CreatedAt: time.Now(),
+	}
+
+	if err := putToken(ctx, tokenKey(token), record); err != nil {
+		return "", nil, err
+	}
+
+	return token, record, nil
+}
+
+// ParseAPIToken returns the record of a valid, unrevoked token
+func ParseAPIToken(ctx context.Context, token string) (*APIToken, error) {
+	if !IsAPIToken(token) {
+		return nil, ErrInvalidToken
+	}
+
+	data, err := services.Store.Get(ctx, tokenKey(token))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record APIToken
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	if record.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &record, nil
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, tokenPrefix)
+}
+
+// ListAPITokens returns the tokens of `owner`, oldest first
+func ListAPITokens(ctx context.Context, owner string) ([]APIToken, error) {
+	tokens := []APIToken{}
+
+	err := eachToken(ctx, func(_ string, record *APIToken) error {
+		if record.Owner == owner {
+			tokens = append(tokens, *record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(tokens, func(a, b APIToken) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes the token `id` of `owner`. The record is kept so
+// sheets uploaded with it can still be traced back.
+func RevokeAPIToken(ctx context.Context, owner, id string) error {
+	errFound := errors.New("found")
+
+	err := eachToken(ctx, func(key string, record *APIToken) error {
+		if record.ID != id || record.Owner != owner {
+			return nil
+		}
+
+		if record.RevokedAt == nil {
+			now := time.Now()
+			record.RevokedAt = &now
+			if err := putToken(ctx, key, record); err != nil {
+				return err
+			}
+		}
+		return errFound
+	})
+
+	switch {
+	case errors.Is(err, errFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return ErrTokenNotFound
+	}
+}
+
+func eachToken(ctx context.Context, fn func(key string, record *APIToken) error) error {
+	objects, err := services.Store.List(ctx, "auth/tokens/")
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		data, err := services.Store.Get(ctx, obj.Key)
+		if err != nil {
+			return err
+		}
+
+		var record APIToken
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+
+		if err := fn(obj.Key, &record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func putToken(ctx context.Context, key string, record *APIToken) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return services.Store.Put(ctx, key, data)
+}
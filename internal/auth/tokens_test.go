@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+)
+
+func TestAPITokenStoredHashed(t *testing.T) {
+	ctx := context.Background()
+	setupAuth(t)
+
+	token, record, err := CreateAPIToken(ctx, testEmail, "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIToken(token) {
+		t.Fatalf("token %q lacks the %s prefix", token, tokenPrefix)
+	}
+
+	objects, err := services.Store.List(ctx, "auth/tokens/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Key != "auth/tokens/"+hashValue(token)+".json" {
+		t.Fatalf("stored %+v, want one record under the hash of the token", objects)
+	}
+	data, err := services.Store.Get(ctx, objects[0].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := strings.TrimPrefix(token, tokenPrefix)
+	if strings.Contains(objects[0].Key, secret) || strings.Contains(string(data), secret) {
+		t.Errorf("the token itself is stored: %s %s", objects[0].Key, data)
+	}
+
+	parsed, err := ParseAPIToken(ctx, token)
+	if err != nil || parsed.ID != record.ID || parsed.Owner != testEmail {
+		t.Fatalf("got %+v, %v, want the record of the token", parsed, err)
+	}
+	if _, err := ParseAPIToken(ctx, token+"x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("altered token: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRevokeAPIToken(t *testing.T) {
+	ctx := context.Background()
+	setupAuth(t)
+
+	token, record, err := CreateAPIToken(ctx, testEmail, "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RevokeAPIToken(ctx, "john@webpoint.io", record.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("revoked by someone else: got %v, want ErrTokenNotFound", err)
+	}
+	if _, err := ParseAPIToken(ctx, token); err != nil {
+		t.Fatalf("token refused before its revocation: %v", err)
+	}
+
+	if err := RevokeAPIToken(ctx, testEmail, record.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAPIToken(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("revoked token: got %v, want ErrInvalidToken", err)
+	}
+
+	// kept so uploads can still be traced back
+	tokens, err := ListAPITokens(ctx, testEmail)
+	if err != nil || len(tokens) != 1 || tokens[0].RevokedAt == nil {
+		t.Errorf("got %+v, %v, want the revoked token listed", tokens, err)
+	}
+}
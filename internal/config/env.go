@@ -3,8 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
 )
 
 type envStruct struct {
@@ -13,17 +17,89 @@ type envStruct struct {
 	SMTPHost     string
 	SMTPPort     string
 	FrontHost    string
+
+	// every FRONTEND_HOST, comma separated, allowed to call the API with the
+	// session cookie, sheet links point at the first one, FrontHost
+	FrontOrigins []string
+
+	SessionSecret string
+	LoginDomain   string
+	AdminEmails   []string
+
+	OutboxWorkers int
+
+	SlackWebhookURL     string
+	TeamsWebhookURL     string
+	DiscordWebhookURL   string
+	NotifyWebhookURL    string
+	NotifyWebhookSecret string
+
+	// credentials for sheets generated from remote repositories, cloned no
+	// deeper than RemoteMaxDepth commits per branch
+	Git            report.RemoteAuth
+	RemoteMaxDepth int
+
+	// periods of remote sheets, unless the request sets its own
+	WeekStart string
+	Timezone  string
+
+	Storage storage.Config
 }
 
 var Env *envStruct
 
+// sessions are signed with HMAC-SHA256, a shorter key is easier to guess
+const minSecretLength = 32
+
 func LoadEnv() *envStruct {
+	getEnv("FRONTEND_HOST")
+	frontOrigins := getListEnv("FRONTEND_HOST")
+	for i, origin := range frontOrigins {
+		frontOrigins[i] = strings.TrimSuffix(origin, "/")
+	}
+
 	Env = &envStruct{
 		SMTPUsername: getEnv("SMTP_USERNAME"),
 		SMTPPassword: getEnv("SMTP_PASSWORD"),
 		SMTPHost:     getEnv("SMTP_HOST"),
 		SMTPPort:     getEnv("SMTP_PORT"),
-		FrontHost:    getEnv("FRONTEND_HOST"),
+		FrontHost:    firstOr(frontOrigins, ""),
+		FrontOrigins: frontOrigins,
+
+		SessionSecret: getSecretEnv("SESSION_SECRET", minSecretLength),
+		LoginDomain:   getOptEnv("LOGIN_DOMAIN", "webpoint.io"),
+		AdminEmails:   getListEnv("ADMIN_EMAILS"),
+
+		OutboxWorkers: getIntEnv("OUTBOX_WORKERS", 2),
+
+		SlackWebhookURL:     getOptEnv("SLACK_WEBHOOK_URL", ""),
+		TeamsWebhookURL:     getOptEnv("TEAMS_WEBHOOK_URL", ""),
+		DiscordWebhookURL:   getOptEnv("DISCORD_WEBHOOK_URL", ""),
+		NotifyWebhookURL:    getOptEnv("NOTIFY_WEBHOOK_URL", ""),
+		NotifyWebhookSecret: getOptEnv("NOTIFY_WEBHOOK_SECRET", ""),
+
+		Git: report.RemoteAuth{
+			Username:         getOptEnv("GIT_USERNAME", ""),
+			Password:         getOptEnv("GIT_PASSWORD", ""),
+			SSHKeyPath:       getOptEnv("GIT_SSH_KEY", ""),
+			SSHKeyPassphrase: getOptEnv("GIT_SSH_KEY_PASSPHRASE", ""),
+		},
+		RemoteMaxDepth: getIntEnv("REMOTE_MAX_DEPTH", 5000),
+
+		WeekStart: getOptEnv("WEEK_START", "monday"),
+		Timezone:  getOptEnv("TIMEZONE", "Local"),
+
+		Storage: storage.Config{
+			Driver:    getOptEnv("STORAGE_DRIVER", "local"),
+			Path:      getOptEnv("STORAGE_PATH", "out"),
+			DSN:       getOptEnv("STORAGE_SQLITE_DSN", "sheet-happens.db"),
+			Endpoint:  getOptEnv("S3_ENDPOINT", ""),
+			Bucket:    getOptEnv("S3_BUCKET", ""),
+			Region:    getOptEnv("S3_REGION", ""),
+			AccessKey: getOptEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getOptEnv("S3_SECRET_KEY", ""),
+			UseSSL:    getOptEnv("S3_USE_SSL", "true") == "true",
+		},
 	}
 	return Env
 }
@@ -37,11 +113,54 @@ func getEnv(varName string) string {
 	return value
 }
 
-// // getOptEnv retrieves the value of the environment variable or returns a default value if not set
-// func getOptEnv(varName, defaultValue string) string {
-// 	value, exists := os.LookupEnv(varName)
-// 	if !exists {
-// 		return defaultValue
-// 	}
-// 	return value
-// }
+// getSecretEnv retrieves the environment variable or panics if it is not set
+// or shorter than `minLength` bytes
+func getSecretEnv(varName string, minLength int) string {
+	value := getEnv(varName)
+	if len(value) < minLength {
+		panic(fmt.Sprintf("%s must be at least %d bytes long", varName, minLength))
+	}
+	return value
+}
+
+// getOptEnv retrieves the value of the environment variable or returns a default value if not set
+func getOptEnv(varName, defaultValue string) string {
+	value, exists := os.LookupEnv(varName)
+	if !exists {
+		return defaultValue
+	}
+	return value
+}
+
+// getListEnv splits a comma separated environment variable, empty if not set
+func getListEnv(varName string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(varName), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// firstOr returns the first of `values` or `fallback` when there are none
+func firstOr(values []string, fallback string) string {
+	if len(values) == 0 {
+		return fallback
+	}
+	return values[0]
+}
+
+// getIntEnv parses the environment variable as an integer or returns a default value if not set
+func getIntEnv(varName string, defaultValue int) int {
+	value, exists := os.LookupEnv(varName)
+	if !exists {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("%s must be a number", varName))
+	}
+	return n
+}
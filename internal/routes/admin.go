@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/responder"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+)
+
+func adminRoutes(r *echo.Group) {
+	// eg: /admin/outbox?status=dead
+	r.GET("/outbox", func(c echo.Context) error {
+		jobs, err := services.ListOutbox(c.Request().Context(), c.QueryParam("status"))
+		if err != nil {
+			return err
+		}
+
+		return responder.Success(c, jobs)
+	})
+
+	r.POST("/outbox/:id/retry", func(c echo.Context) error {
+		job, err := services.RetryOutboxJob(c.Request().Context(), c.Param("id"))
+		if errors.Is(err, services.ErrJobNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, services.ErrJobNotDead) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		return responder.Success(c, job)
+	})
+}
@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
+	"github.com/webpointsolutions/sheet-happens/internal/responder"
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+)
+
+func authRoutes(r *echo.Group) {
+	// emails a one-time code, the login finishes on /login/verify
+	r.POST("/login", func(c echo.Context) error {
+		var body types.LoginRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		email, err := auth.NormalizeEmail(body.Email)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+
+		if err := auth.SendLoginCode(c.Request().Context(), email); err != nil {
+			if errors.Is(err, auth.ErrResendTooSoon) || errors.Is(err, auth.ErrLoginLocked) {
+				return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+			}
+			log.Println("could not send login code:", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "could not send login code")
+		}
+
+		res := map[string]any{
+			"message": "Login code sent",
+		}
+
+		return responder.Success(c, res)
+	})
+
+	r.POST("/login/verify", func(c echo.Context) error {
+		var body types.LoginVerifyRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		email, err := auth.NormalizeEmail(body.Email)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+
+		if err := auth.VerifyLoginCode(c.Request().Context(), email, body.Code); err != nil {
+			if errors.Is(err, auth.ErrInvalidCode) || errors.Is(err, auth.ErrTooManyAttempts) {
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+			if errors.Is(err, auth.ErrLoginLocked) {
+				return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+			}
+			return err
+		}
+
+		token, session, err := auth.IssueSession(email)
+		if err != nil {
+			return err
+		}
+
+		c.SetCookie(&http.Cookie{
+			Name:     auth.SessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  session.ExpiresAt,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		res := types.LoginResponse{
+			Name:      session.Name,
+			Email:     session.Email,
+			ExpiresAt: session.ExpiresAt,
+		}
+
+		return responder.Success(c, res)
+	})
+
+	r.POST("/logout", func(c echo.Context) error {
+		c.SetCookie(&http.Cookie{
+			Name:     auth.SessionCookie,
+			Value:    "",
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		res := map[string]any{
+			"message": "Logged out",
+		}
+
+		return responder.Success(c, res)
+	})
+
+	r.GET("/me", func(c echo.Context) error {
+		return responder.Success(c, auth.CurrentSession(c))
+	}, auth.RequireSession)
+
+	tokenRoutes(r.Group("/tokens", auth.RequireSession))
+}
+
+// personal API tokens, used by the CLI to upload sheets
+func tokenRoutes(r *echo.Group) {
+	r.GET("", func(c echo.Context) error {
+		tokens, err := auth.ListAPITokens(c.Request().Context(), auth.CurrentSession(c).Email)
+		if err != nil {
+			return err
+		}
+
+		return responder.Success(c, tokens)
+	})
+
+	r.POST("", func(c echo.Context) error {
+		session := auth.CurrentSession(c)
+
+		var body types.TokenCreateRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+		if strings.TrimSpace(body.Name) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "token name is required")
+		}
+
+		token, record, err := auth.CreateAPIToken(c.Request().Context(), session.Email, strings.TrimSpace(body.Name))
+		if err != nil {
+			return err
+		}
+
+		res := map[string]any{
+			"message": "Token created, it will not be shown again",
+			"token":   token,
+			"record":  record,
+		}
+
+		return responder.Success(c, res, http.StatusCreated)
+	})
+
+	r.DELETE("/:id", func(c echo.Context) error {
+		err := auth.RevokeAPIToken(c.Request().Context(), auth.CurrentSession(c).Email, c.Param("id"))
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		res := map[string]any{
+			"message": "Token revoked",
+		}
+
+		return responder.Success(c, res)
+	})
+}
@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+	"github.com/webpointsolutions/sheet-happens/internal/responder"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+	"github.com/webpointsolutions/sheet-happens/internal/utils"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// how long cloning and reading a remote repository may take
+const remoteTimeout = 5 * time.Minute
+
+// sheets generated on the server from a repository URL, cloned into memory
+// with the server's git credentials so only admins may use it
+func remoteRoutes(r *echo.Group) {
+	r.POST("/remote", func(c echo.Context) error {
+		var body types.RemoteSheetRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		// file:// and local paths would read the server's own disk
+		protocol, err := report.RemoteProtocol(body.URL)
+		if err != nil || (protocol != "https" && protocol != "ssh") {
+			return echo.NewHTTPError(http.StatusBadRequest, "url must be an https or ssh repository URL")
+		}
+
+		// the clone is held in memory, full histories only up to the cap
+		maxDepth := config.Env.RemoteMaxDepth
+		if body.Depth < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "depth can't be negative")
+		}
+		if maxDepth > 0 && body.Depth > maxDepth {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("depth can be at most %d", maxDepth))
+		}
+		if body.Depth == 0 {
+			body.Depth = maxDepth
+		}
+
+		receivers, err := parseReceivers(c)
+		if err != nil {
+			return err
+		}
+
+		commitRange, err := remoteRange(body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), remoteTimeout)
+		defer cancel()
+
+		rep, err := report.Generate(ctx, report.Options{
+			Remotes:        []string{body.URL},
+			RemoteAuth:     config.Env.Git,
+			CloneDepth:     body.Depth,
+			Branch:         body.Branch,
+			SinceDays:      body.SinceDays,
+			Range:          commitRange,
+			Period:         body.Period,
+			Description:    body.Description,
+			Authors:        body.Authors,
+			ExcludeAuthors: body.ExcludeAuthors,
+			AllAuthors:     len(body.Authors) == 0,
+			Paths:          body.Paths,
+			Tags:           body.Tags,
+			Refs:           body.Refs,
+			ExcludeRefs:    body.ExcludeRefs,
+
+			HashColumn:      body.Hash,
+			ShortHashColumn: body.ShortHash,
+			BranchColumn:    body.ShowBranch,
+			DiffStats:       body.DiffStats,
+
+			Merges:          body.Merges,
+			Reverts:         body.Reverts,
+			ExcludeMessages: body.ExcludeMessages,
+			ExcludeBots:     body.ExcludeBots,
+			ShowDropped:     body.ShowDropped,
+		})
+		if errors.Is(err, report.ErrNoCommits) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		if errors.Is(err, report.ErrInvalidOption) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+		}
+
+		fileName := fmt.Sprintf("%d_%s_%s_log.csv", time.Now().Unix(), utils.RepoSlug(rep.Repo), utils.Generate4DigitCode())
+		id := utils.GetSheetID(fileName)
+
+		session := auth.CurrentSession(c)
+		meta := rep.Meta()
+		meta.ID = id
+		meta.FileName = fileName
+		meta.UploadedAt = time.Now()
+		meta.UploadedBy = session.Email
+		meta.TokenID = session.TokenID
+		meta.Receivers = receivers
+		if meta.GeneratedBy == "" {
+			meta.GeneratedBy = session.Email
+		}
+
+		rows := rep.Rows()
+		saved, err := services.UploadSheet(c.Request().Context(), services.SheetUpload{
+			Meta:   meta,
+			Draft:  body.Draft,
+			Header: rows[0],
+			Rows:   rows[1:],
+		})
+		if err != nil {
+			return sheetError(err)
+		}
+
+		if saved.Status != report.SheetStatusDraft {
+			if err := services.NotifySheetSubmitted(c.Request().Context(), *saved); err != nil {
+				log.Printf("could not notify the reviewers of %s: %v", saved.ID, err)
+			}
+		}
+
+		res := map[string]any{
+			"message":  "Successfully generated the CSV",
+			"filename": id,
+			"status":   saved.Status,
+			"commits":  len(rep.Records),
+		}
+
+		return responder.Success(c, res)
+	}, auth.RequireAdmin)
+}
+
+// remoteRange resolves the request's range like the CLI does, in the
+// request's week start and timezone or else the server's
+func remoteRange(body types.RemoteSheetRequest) (report.DateRange, error) {
+	query := report.RangeQuery{
+		SinceDays: body.SinceDays,
+		Since:     body.Since,
+		Until:     body.Until,
+		Period:    body.Period,
+		WeekStart: cmp.Or(body.WeekStart, config.Env.WeekStart),
+		Timezone:  cmp.Or(body.Timezone, config.Env.Timezone),
+	}
+	return query.Resolve(time.Now())
+}
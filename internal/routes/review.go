@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"log"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
+	"github.com/webpointsolutions/sheet-happens/internal/responder"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// approval workflow, see services.TransitionSheet for who may do what
+func reviewRoutes(r *echo.Group) {
+	// submits a draft or resubmits a rejected sheet
+	r.POST("/:id/submit", func(c echo.Context) error {
+		meta, err := services.ReadMeta(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sheetError(err)
+		}
+
+		to := report.SheetStatusSubmitted
+		if meta.Status == report.SheetStatusRejected {
+			to = report.SheetStatusResubmitted
+		}
+
+		return transition(c, to, "")
+	})
+
+	// takes a pending sheet back to a draft so its uploader can edit it
+	r.POST("/:id/withdraw", func(c echo.Context) error {
+		return transition(c, report.SheetStatusDraft, "")
+	})
+
+	r.POST("/:id/approve", func(c echo.Context) error {
+		return transition(c, report.SheetStatusApproved, "")
+	})
+
+	r.POST("/:id/reject", func(c echo.Context) error {
+		var body types.ReviewRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		return transition(c, report.SheetStatusRejected, body.Reason)
+	})
+}
+
+func transition(c echo.Context, to, reason string) error {
+	session := auth.CurrentSession(c)
+
+	meta, err := services.TransitionSheet(c.Request().Context(), c.Param("id"), to, session.Email, reason)
+	if err != nil {
+		return sheetError(err)
+	}
+
+	// submitting notifies the reviewers, reviewing notifies the submitter and
+	// a withdrawn draft is announced once it is submitted again
+	notify := services.NotifyStatusChange
+	switch to {
+	case report.SheetStatusDraft:
+		return responder.Success(c, meta)
+	case report.SheetStatusSubmitted, report.SheetStatusResubmitted:
+		notify = services.NotifySheetSubmitted
+	}
+
+	// the transition is saved by now, failing the request would only make the
+	// client retry a transition that already happened
+	if err := notify(c.Request().Context(), *meta); err != nil {
+		log.Printf("could not notify about %s being %s: %v", meta.ID, meta.Status, err)
+	}
+
+	return responder.Success(c, meta)
+}
@@ -1,92 +1,61 @@
 package routes
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/mail"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
 	"github.com/webpointsolutions/sheet-happens/internal/responder"
 	"github.com/webpointsolutions/sheet-happens/internal/services"
-	"github.com/webpointsolutions/sheet-happens/internal/types"
 	"github.com/webpointsolutions/sheet-happens/internal/utils"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
 )
 
 func Routes(r *echo.Group) {
-	r.GET("/csv/:id", func(c echo.Context) error {
-		id := c.Param("id")
-		filename := id + ".csv"
-		filePath := filepath.Join("out", filename) // looks inside ./out/{id}
-
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			fmt.Println("")
-			return echo.NewHTTPError(http.StatusNotFound, "file not found")
-		}
-
-		return c.File(filePath)
-	})
-
-	r.POST("/login", func(c echo.Context) error {
-		var body types.LoginRequest
-		if err := c.Bind(&body); err != nil {
-			return echo.NewHTTPError(http.StatusUnauthorized, err)
-		}
+	authRoutes(r)
 
-		if !strings.Contains(body.Email, "@webpoint.io") {
-			return echo.NewHTTPError(http.StatusUnauthorized, "invalid email provided")
-		}
+	// every sheet route needs a logged in user, uploads also take API tokens
+	csv := r.Group("/csv", auth.RequireSession)
 
-		emailSplited := strings.Split(body.Email, "@")
+	// serves CSV, or JSON with ?format=json or an Accept: application/json header
+	csv.GET("/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
 
-		username := func() string {
-			name := emailSplited[0]
-			capitalize := func(s string) string {
-				if len(s) == 0 {
-					return s
-				}
-				return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+		if !wantsJSON(c) {
+			data, err := services.ReadSheetData(ctx, c.Param("id"))
+			if err != nil {
+				return sheetError(err)
 			}
 
-			if !strings.Contains(name, ".") {
-				return capitalize(name)
-			}
+			return c.Blob(http.StatusOK, "text/csv; charset=utf-8", data)
+		}
 
-			names := strings.Split(name, ".")
-			return fmt.Sprintf("%s %s", capitalize(names[0]), capitalize(names[1]))
-		}()
+		header, rows, err := services.ReadSheet(ctx, c.Param("id"))
+		if err != nil {
+			return sheetError(err)
+		}
 
-		res := types.LoginResponse{Name: username}
+		sheet := report.SheetToJSON(header, rows)
+		if meta, err := services.ReadMeta(ctx, c.Param("id")); err == nil {
+			sheet.Meta = meta
+		}
 
-		return responder.Success(c, res)
+		return responder.Success(c, sheet)
 	})
 
 	r.POST("/csv", func(c echo.Context) error {
-		var to string
-		var cc []string
-		receiver := c.QueryParam("receiver")
-		if receiver == "" {
-			to = "aashutosh.poudel@webpoint.io"
-		} else {
-			addressList, err := mail.ParseAddressList(receiver)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusBadRequest, "invalid receiver format")
-			}
-
-			if len(addressList) > 0 {
-				to = addressList[0].Address
-			}
-
-			if len(addressList) > 1 {
-				for _, addr := range addressList[1:] {
-					cc = append(cc, addr.Address)
-				}
-			}
+		receivers, err := parseReceivers(c)
+		if err != nil {
+			return err
 		}
 
 		file, err := c.FormFile("file")
@@ -100,82 +69,124 @@ func Routes(r *echo.Group) {
 		}
 		defer src.Close()
 
-		// Create destination file
-		dstPath, newFileName := utils.GetDestinationPath(file.Filename)
-		dst, err := os.Create(dstPath)
-		if err != nil {
-			return err
-		}
-		defer dst.Close()
+		upload := services.SheetUpload{Draft: c.QueryParam("draft") == "true"}
 
-		// Copy content
-		if _, err := io.Copy(dst, src); err != nil {
-			return err
+		// metadata sent by the CLI, the server fills in the rest
+		var meta report.SheetMeta
+		if raw := c.FormValue("meta"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid meta format")
+			}
 		}
 
-		// send email on background
-		go func() {
-			attachment := services.EmailAttachment{
-				FileName:    newFileName,
-				ContentType: "application/octet-stream",
+		// sheets written with --format json carry their rows and meta
+		if strings.EqualFold(filepath.Ext(file.Filename), ".json") {
+			var sheet report.SheetJSON
+			if err := json.NewDecoder(src).Decode(&sheet); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid JSON sheet")
 			}
-
-			attachment.Data, err = os.ReadFile(dstPath)
-			if err != nil {
-				log.Printf("could not read the file after saving: %v \n", err)
-				return
+			if sheet.Meta != nil && c.FormValue("meta") == "" {
+				meta = *sheet.Meta
 			}
+			upload.Header, upload.Rows = sheet.Header, sheet.Rows
+		} else if upload.Data, err = io.ReadAll(src); err != nil {
+			return err
+		}
 
-			data := map[string]any{
-				"Name": "Aashutosh",
-				"Link": services.GetFileFrontendUrl(newFileName),
-			}
+		newFileName := utils.GetSheetID(file.Filename)
+		session := auth.CurrentSession(c)
+
+		upload.Draft = upload.Draft || meta.Status == report.SheetStatusDraft
+		meta.ID = newFileName
+		meta.FileName = file.Filename
+		meta.UploadedAt = time.Now()
+		meta.UploadedBy = session.Email
+		meta.TokenID = session.TokenID
+		meta.Receivers = receivers
+		if meta.GeneratedBy == "" {
+			meta.GeneratedBy = session.Email
+		}
+		if meta.Repo == "" {
+			meta.Repo = utils.GetRepoNameFromFileName(file.Filename)
+		}
+		upload.Meta = meta
 
-			reponame := utils.GetRepoNameFromFileName(file.Filename)
+		// Save as a new revision of the destination sheet
+		saved, err := services.UploadSheet(c.Request().Context(), upload)
+		var validationErr *services.SheetValidationError
+		if errors.As(err, &validationErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid sheet: "+validationErr.Error())
+		}
+		if err != nil {
+			return sheetError(err)
+		}
 
-			location, err := time.LoadLocation("Asia/Kathmandu")
-			if err != nil {
-				fmt.Println("Error loading location:", err)
-				return
+		// drafts are sent once they are submitted. The sheet is saved by now, a
+		// failed notification mustn't make the client upload it again.
+		if saved.Status != report.SheetStatusDraft {
+			if err := services.NotifySheetSubmitted(c.Request().Context(), *saved); err != nil {
+				log.Printf("could not notify the reviewers of %s: %v", saved.ID, err)
 			}
+		}
 
-			// Get the current time in UTC +5:45
-			currentTime := time.Now().In(location)
-
-			// Format the time as "March 25 2024, 5:45 PM"
-			formattedTime := currentTime.Format("January 2 2006, 3:04 PM")
-
-			subject := fmt.Sprintf("TimeSheet received for %s, %s", reponame, formattedTime)
+		res := map[string]any{
+			"message":  "Successfully uploaded the CSV",
+			"filename": newFileName,
+			"status":   saved.Status,
+		}
 
-			emailParams := services.EmailRequestParams{
-				To:              to,
-				CC:              cc,
-				EmailAttachment: []services.EmailAttachment{attachment},
-				EmailTemplate:   "email",
-				Subject:         subject,
-				TemplateParams:  data,
-			}
+		return responder.Success(c, res)
+	}, auth.RequireUploader)
 
-			emailError := services.SendEmailWithAttachment(emailParams)
+	sheetRoutes(csv)
+	versionRoutes(csv)
+	metaRoutes(csv)
+	reviewRoutes(csv)
+	remoteRoutes(csv)
 
-			if emailError != nil {
-				log.Println("", emailError)
-				return
-			}
+	adminRoutes(r.Group("/admin", auth.RequireSession, auth.RequireAdmin))
+}
 
-			if err := services.SendSlackMessage(services.MessageBody{
-				FileName: newFileName,
-				Url:      services.GetFileFrontendUrl(newFileName),
-			}); err != nil {
-				log.Println(err)
-			}
-		}()
+func wantsJSON(c echo.Context) bool {
+	if format := c.QueryParam("format"); format != "" {
+		return format == "json"
+	}
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON)
+}
 
-		res := map[string]any{
-			"message":  "Successfully uploaded the CSV",
-			"filename": newFileName,
+// bindJSON binds a JSON request body, form bodies are refused so a cross-site
+// HTML form can't change anything with the visitor's session cookie
+func bindJSON(c echo.Context, body any) error {
+	req := c.Request()
+	if req.ContentLength != 0 {
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+		if mediaType != echo.MIMEApplicationJSON {
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType, "request body must be JSON")
 		}
+	}
 
-		return responder.Success(c, res)
-	})
+	if err := c.Bind(body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	return nil
+}
+
+// parseReceivers reads the ?receiver address list, the first one is the
+// recipient and the rest are copied
+func parseReceivers(c echo.Context) ([]string, error) {
+	receiver := c.QueryParam("receiver")
+	if receiver == "" {
+		return []string{"aashutosh.poudel@webpoint.io"}, nil
+	}
+
+	addressList, err := mail.ParseAddressList(receiver)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid receiver format")
+	}
+
+	receivers := make([]string, 0, len(addressList))
+	for _, addr := range addressList {
+		receivers = append(receivers, addr.Address)
+	}
+	return receivers, nil
 }
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+const testEmail = "jane.doe@webpoint.io"
+
+// newTestServer loads a test environment, points services.Store at a local
+// backend in a temporary directory and returns the routes
+func newTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	for _, name := range []string{"SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_HOST", "SMTP_PORT"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("FRONTEND_HOST", "https://sheets.webpoint.io")
+	t.Setenv("SESSION_SECRET", strings.Repeat("s", 32))
+	t.Setenv("LOGIN_DOMAIN", "webpoint.io")
+
+	previousEnv := config.Env
+	config.LoadEnv()
+	t.Cleanup(func() { config.Env = previousEnv })
+
+	s, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousStore := services.Store
+	services.Store = s
+	t.Cleanup(func() { services.Store = previousStore })
+
+	e := echo.New()
+	Routes(e.Group(""))
+	return e
+}
+
+// uploadRequest is a draft upload of a one row sheet
+func uploadRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	file, err := mw.CreateFormFile("file", "1725815494_sheet-happens_6448_log.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte("Date,Author Name,Commit Type,Scope,Description,TimeStamp\n2025-04-01 09:00:00,Jane,feat,,login form,0.5\n"))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/csv?draft=true", &body)
+	r.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	return r
+}
+
+func TestAPITokenRoutes(t *testing.T) {
+	e := newTestServer(t)
+	ctx := context.Background()
+
+	token, record, err := auth.CreateAPIToken(ctx, testEmail, "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(r *http.Request) int {
+		r.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := serve(uploadRequest(t)); code != http.StatusOK {
+		t.Fatalf("upload with a token: got %d, want 200", code)
+	}
+	meta, err := services.ReadMeta(ctx, "1725815494_sheet-happens_6448_log_final")
+	if err != nil || meta.UploadedBy != testEmail || meta.TokenID != record.ID {
+		t.Fatalf("got %+v, %v, want the sheet uploaded by the token's owner", meta, err)
+	}
+
+	// everything else needs a login
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/csv/1725815494_sheet-happens_6448_log_final", nil),
+		httptest.NewRequest(http.MethodGet, "/me", nil),
+		httptest.NewRequest(http.MethodGet, "/tokens", nil),
+		httptest.NewRequest(http.MethodPost, "/tokens", strings.NewReader(`{"name":"another"}`)),
+	} {
+		if code := serve(r); code != http.StatusForbidden {
+			t.Errorf("%s %s with a token: got %d, want 403", r.Method, r.URL.Path, code)
+		}
+	}
+
+	if err := auth.RevokeAPIToken(ctx, testEmail, record.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(uploadRequest(t)); code != http.StatusUnauthorized {
+		t.Errorf("upload with a revoked token: got %d, want 401", code)
+	}
+}
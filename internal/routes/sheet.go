@@ -0,0 +1,260 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/webpointsolutions/sheet-happens/internal/auth"
+	"github.com/webpointsolutions/sheet-happens/internal/responder"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+)
+
+func sheetRoutes(r *echo.Group) {
+	// replaces every row of the sheet
+	r.PUT("/:id", func(c echo.Context) error {
+		id := c.Param("id")
+
+		var body types.SheetUpdateRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		version, rows, err := services.UpdateSheet(c.Request().Context(), id, auth.CurrentSession(c).Email, func(header []string, _ [][]string) ([][]string, error) {
+			if body.Header != nil && !slices.Equal(body.Header, header) {
+				return nil, &services.SheetValidationError{Row: -1, Message: "does not match the sheet header"}
+			}
+			return body.Rows, nil
+		})
+		if err != nil {
+			return sheetError(err)
+		}
+
+		res := map[string]any{
+			"message": "Successfully saved the CSV",
+			"id":      id,
+			"rows":    len(rows),
+			"version": version,
+		}
+
+		return responder.Success(c, res)
+	})
+
+	// replaces a single row, `row` is the zero based index below the header
+	r.PATCH("/:id/rows/:row", func(c echo.Context) error {
+		id := c.Param("id")
+
+		var body types.RowUpdateRequest
+		if err := bindJSON(c, &body); err != nil {
+			return err
+		}
+
+		index, err := strconv.Atoi(c.Param("row"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid row index")
+		}
+
+		version, rows, err := services.UpdateSheet(c.Request().Context(), id, auth.CurrentSession(c).Email, func(_ []string, rows [][]string) ([][]string, error) {
+			if index < 0 || index >= len(rows) {
+				return nil, echo.NewHTTPError(http.StatusNotFound, "row not found")
+			}
+			rows[index] = body.Row
+			return rows, nil
+		})
+		if err != nil {
+			return sheetError(err)
+		}
+
+		res := map[string]any{
+			"message": "Successfully updated the row",
+			"id":      id,
+			"rows":    len(rows),
+			"version": version,
+		}
+
+		return responder.Success(c, res)
+	})
+
+	r.DELETE("/:id/rows/:row", func(c echo.Context) error {
+		id := c.Param("id")
+
+		index, err := strconv.Atoi(c.Param("row"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid row index")
+		}
+
+		version, rows, err := services.UpdateSheet(c.Request().Context(), id, auth.CurrentSession(c).Email, func(_ []string, rows [][]string) ([][]string, error) {
+			if index < 0 || index >= len(rows) {
+				return nil, echo.NewHTTPError(http.StatusNotFound, "row not found")
+			}
+			return slices.Delete(rows, index, index+1), nil
+		})
+		if err != nil {
+			return sheetError(err)
+		}
+
+		res := map[string]any{
+			"message": "Successfully deleted the row",
+			"id":      id,
+			"rows":    len(rows),
+			"version": version,
+		}
+
+		return responder.Success(c, res)
+	})
+}
+
+func metaRoutes(r *echo.Group) {
+	// eg: /csv?repo=sheet-happens&from=2025-04-01&to=2025-04-30&q=login&sort=repo&order=asc&page=2
+	r.GET("", func(c echo.Context) error {
+		query := types.SheetListQuery{
+			Repo:     c.QueryParam("repo"),
+			Uploader: c.QueryParam("uploader"),
+			Status:   c.QueryParam("status"),
+			Text:     c.QueryParam("q"),
+			Sort:     c.QueryParam("sort"),
+			Desc:     c.QueryParam("order") != "asc",
+			Page:     1,
+			Limit:    20,
+		}
+
+		var err error
+		if q := c.QueryParam("from"); q != "" {
+			if query.From, err = parseDateParam(q); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid from date")
+			}
+		}
+		if q := c.QueryParam("to"); q != "" {
+			if query.To, err = parseDateParam(q); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid to date")
+			}
+			// a plain date includes the whole day
+			if len(q) == len(time.DateOnly) {
+				query.To = query.To.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		}
+		if q := c.QueryParam("page"); q != "" {
+			if query.Page, err = strconv.Atoi(q); err != nil || query.Page < 1 {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid page")
+			}
+		}
+		if q := c.QueryParam("limit"); q != "" {
+			if query.Limit, err = strconv.Atoi(q); err != nil || query.Limit < 1 || query.Limit > 100 {
+				return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 100")
+			}
+		}
+
+		list, err := services.ListSheets(c.Request().Context(), query)
+		if err != nil {
+			return err
+		}
+
+		return responder.Success(c, list)
+	})
+
+	r.GET("/:id/meta", func(c echo.Context) error {
+		meta, err := services.ReadMeta(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sheetError(err)
+		}
+
+		return responder.Success(c, meta)
+	})
+}
+
+func versionRoutes(r *echo.Group) {
+	r.GET("/:id/versions", func(c echo.Context) error {
+		versions, err := services.ListVersions(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return sheetError(err)
+		}
+
+		return responder.Success(c, versions)
+	})
+
+	r.GET("/:id/versions/:n", func(c echo.Context) error {
+		n, err := strconv.Atoi(c.Param("n"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid version")
+		}
+
+		data, err := services.ReadVersionData(c.Request().Context(), c.Param("id"), n)
+		if err != nil {
+			return sheetError(err)
+		}
+
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", data)
+	})
+
+	// compares two revisions, defaults to the latest one against its predecessor
+	r.GET("/:id/diff", func(c echo.Context) error {
+		id := c.Param("id")
+
+		versions, err := services.ListVersions(c.Request().Context(), id)
+		if err != nil {
+			return sheetError(err)
+		}
+
+		to := versions[len(versions)-1].Version
+		if q := c.QueryParam("to"); q != "" {
+			if to, err = strconv.Atoi(q); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid to version")
+			}
+		}
+
+		from := max(to-1, 1)
+		if q := c.QueryParam("from"); q != "" {
+			if from, err = strconv.Atoi(q); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid from version")
+			}
+		}
+
+		diff, err := services.DiffVersions(c.Request().Context(), id, from, to)
+		if err != nil {
+			return sheetError(err)
+		}
+
+		return responder.Success(c, diff)
+	})
+}
+
+// parseDateParam accepts either a plain date or an RFC 3339 timestamp
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// sheetError maps sheet service errors to HTTP errors
+func sheetError(err error) error {
+	var httpErr *echo.HTTPError
+	var validationErr *services.SheetValidationError
+
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.Is(err, services.ErrSheetNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	case errors.Is(err, services.ErrVersionNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "version not found")
+	case errors.As(err, &validationErr):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, validationErr.Error())
+	case errors.Is(err, services.ErrDiffTooLarge):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrSheetLocked):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrNotReviewer), errors.Is(err, services.ErrNotSubmitter), errors.Is(err, services.ErrSelfReview),
+		errors.Is(err, services.ErrNotOwner):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrReasonRequired), errors.Is(err, services.ErrInvalidMeta),
+		errors.Is(err, services.ErrNoReceivers):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return err
+	}
+}
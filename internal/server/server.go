@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/webpointsolutions/sheet-happens/internal/config"
 	"github.com/webpointsolutions/sheet-happens/internal/routes"
 )
 
@@ -17,8 +18,8 @@ func NewServer() http.Handler {
 	mux.Use(middleware.Logger())
 
 	mux.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"*"},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:     config.Env.FrontOrigins,
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 		ExposeHeaders:    []string{echo.HeaderContentDisposition},
 		AllowCredentials: true,
 	}))
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrNotReviewer       = errors.New("only receivers of the sheet can review it")
+	ErrReasonRequired    = errors.New("a reason is required to reject a sheet")
+	ErrNotSubmitter      = errors.New("only the uploader of the sheet can submit or withdraw it")
+	ErrSelfReview        = errors.New("the uploader of a sheet can't review it")
+	ErrNoReceivers       = errors.New("a sheet without receivers can't be submitted")
+)
+
+// sheetTransitions lists the statuses a sheet can move to from each status
+var sheetTransitions = map[string][]string{
+	report.SheetStatusDraft:       {report.SheetStatusSubmitted},
+	report.SheetStatusSubmitted:   {report.SheetStatusApproved, report.SheetStatusRejected, report.SheetStatusDraft},
+	report.SheetStatusResubmitted: {report.SheetStatusApproved, report.SheetStatusRejected, report.SheetStatusDraft},
+	report.SheetStatusRejected:    {report.SheetStatusResubmitted},
+}
+
+// TransitionSheet moves the sheet `id` to status `to` on behalf of `by` and
+// records the change in its history. Submitting and withdrawing a pending
+// sheet back to a draft are limited to the uploader, approving and rejecting
+// to the receivers of the sheet other than the uploader, who picks the
+// receivers.
+func TransitionSheet(ctx context.Context, id, to, by, reason string) (*report.SheetMeta, error) {
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+
+	meta, err := ReadMeta(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transitionTo(meta, to, by, reason); err != nil {
+		return nil, err
+	}
+	if err := SaveMeta(ctx, *meta); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// transitionTo checks that `by` may move `meta` to `to` and records the
+// change, see TransitionSheet
+func transitionTo(meta *report.SheetMeta, to, by, reason string) error {
+	if !slices.Contains(sheetTransitions[meta.Status], to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, meta.Status, to)
+	}
+
+	reason = strings.TrimSpace(reason)
+	switch to {
+	case report.SheetStatusSubmitted, report.SheetStatusResubmitted, report.SheetStatusDraft:
+		if !claimOwner(meta, by) {
+			return ErrNotSubmitter
+		}
+		if to != report.SheetStatusDraft && len(meta.Receivers) == 0 {
+			return ErrNoReceivers
+		}
+	case report.SheetStatusApproved, report.SheetStatusRejected:
+		if strings.EqualFold(meta.UploadedBy, by) {
+			return ErrSelfReview
+		}
+		if !slices.ContainsFunc(meta.Receivers, func(r string) bool { return strings.EqualFold(r, by) }) {
+			return ErrNotReviewer
+		}
+		if to == report.SheetStatusRejected && reason == "" {
+			return ErrReasonRequired
+		}
+	}
+
+	RecordStatus(meta, to, by, reason)
+	return nil
+}
+
+// claimOwner reports whether `by` uploaded `meta`. Sheets from before the
+// uploader was recorded belong to the first one to write them.
+func claimOwner(meta *report.SheetMeta, by string) bool {
+	if meta.UploadedBy == "" {
+		meta.UploadedBy = by
+	}
+	return strings.EqualFold(meta.UploadedBy, by)
+}
+
+// RecordStatus sets the status of `meta` and appends it to the history
+func RecordStatus(meta *report.SheetMeta, status, by, reason string) {
+	meta.Status = status
+	switch status {
+	case report.SheetStatusRejected:
+		meta.Reason = reason
+	case report.SheetStatusApproved:
+		meta.Reason = ""
+	}
+	meta.History = append(meta.History, report.StatusChange{
+		Status: status,
+		By:     by,
+		Reason: reason,
+		At:     time.Now(),
+	})
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+const (
+	testUploader = "jane.doe@webpoint.io"
+	testReviewer = "lead@webpoint.io"
+)
+
+// saveTestSheet stores the test sheet uploaded by testUploader for
+// testReviewer with status `status`
+func saveTestSheet(t *testing.T, status string) {
+	t.Helper()
+
+	saveTestRevisions(t, [][]string{CSVHeader, rowA, rowB})
+	meta := report.SheetMeta{
+		ID:         testSheetID,
+		Repo:       "sheet-happens",
+		UploadedBy: testUploader,
+		Receivers:  []string{testReviewer, "pm@webpoint.io"},
+		Status:     status,
+	}
+	if err := SaveMeta(context.Background(), meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransitionSheet(t *testing.T) {
+	tests := map[string]struct {
+		from, to string
+		by       string
+		reason   string
+		want     error
+	}{
+		"submit draft":                {from: report.SheetStatusDraft, to: report.SheetStatusSubmitted, by: testUploader},
+		"submit someone else's":       {from: report.SheetStatusDraft, to: report.SheetStatusSubmitted, by: testReviewer, want: ErrNotSubmitter},
+		"approve draft":               {from: report.SheetStatusDraft, to: report.SheetStatusApproved, by: testReviewer, want: ErrInvalidTransition},
+		"approve submitted":           {from: report.SheetStatusSubmitted, to: report.SheetStatusApproved, by: testReviewer},
+		"approve as another receiver": {from: report.SheetStatusSubmitted, to: report.SheetStatusApproved, by: "PM@webpoint.io"},
+		"approve own sheet":           {from: report.SheetStatusSubmitted, to: report.SheetStatusApproved, by: testUploader, want: ErrSelfReview},
+		"approve as non receiver":     {from: report.SheetStatusSubmitted, to: report.SheetStatusApproved, by: "dan@webpoint.io", want: ErrNotReviewer},
+		"reject submitted":            {from: report.SheetStatusSubmitted, to: report.SheetStatusRejected, by: testReviewer, reason: "missing hours"},
+		"reject without reason":       {from: report.SheetStatusSubmitted, to: report.SheetStatusRejected, by: testReviewer, reason: "  ", want: ErrReasonRequired},
+		"reject own sheet":            {from: report.SheetStatusSubmitted, to: report.SheetStatusRejected, by: testUploader, reason: "oops", want: ErrSelfReview},
+		"withdraw submitted":          {from: report.SheetStatusSubmitted, to: report.SheetStatusDraft, by: testUploader},
+		"withdraw someone else's":     {from: report.SheetStatusSubmitted, to: report.SheetStatusDraft, by: testReviewer, want: ErrNotSubmitter},
+		"resubmit rejected":           {from: report.SheetStatusRejected, to: report.SheetStatusResubmitted, by: testUploader},
+		"submit rejected":             {from: report.SheetStatusRejected, to: report.SheetStatusSubmitted, by: testUploader, want: ErrInvalidTransition},
+		"approve rejected":            {from: report.SheetStatusRejected, to: report.SheetStatusApproved, by: testReviewer, want: ErrInvalidTransition},
+		"approve resubmitted":         {from: report.SheetStatusResubmitted, to: report.SheetStatusApproved, by: testReviewer},
+		"reject resubmitted":          {from: report.SheetStatusResubmitted, to: report.SheetStatusRejected, by: testReviewer, reason: "still missing"},
+		"withdraw resubmitted":        {from: report.SheetStatusResubmitted, to: report.SheetStatusDraft, by: testUploader},
+		"reject approved":             {from: report.SheetStatusApproved, to: report.SheetStatusRejected, by: testReviewer, reason: "late", want: ErrInvalidTransition},
+		"withdraw approved":           {from: report.SheetStatusApproved, to: report.SheetStatusDraft, by: testUploader, want: ErrInvalidTransition},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			useTestStore(t)
+			saveTestSheet(t, tt.from)
+
+			_, err := TransitionSheet(ctx, testSheetID, tt.to, tt.by, tt.reason)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			meta, err := ReadMeta(ctx, testSheetID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != nil {
+				if meta.Status != tt.from || len(meta.History) != 0 {
+					t.Errorf("refused transition left the sheet %s with %d changes", meta.Status, len(meta.History))
+				}
+				return
+			}
+
+			if meta.Status != tt.to || len(meta.History) != 1 || meta.History[0].By != tt.by {
+				t.Errorf("sheet is %s with history %+v, want %s by %s", meta.Status, meta.History, tt.to, tt.by)
+			}
+			if tt.to == report.SheetStatusRejected && meta.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", meta.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestUpdateSheet(t *testing.T) {
+	tests := map[string]struct {
+		status string
+		by     string
+		want   error
+	}{
+		"draft":                   {status: report.SheetStatusDraft, by: testUploader},
+		"rejected":                {status: report.SheetStatusRejected, by: testUploader},
+		"draft by someone else":   {status: report.SheetStatusDraft, by: testReviewer, want: ErrNotOwner},
+		"submitted":               {status: report.SheetStatusSubmitted, by: testUploader, want: ErrSheetLocked},
+		"resubmitted":             {status: report.SheetStatusResubmitted, by: testUploader, want: ErrSheetLocked},
+		"approved":                {status: report.SheetStatusApproved, by: testUploader, want: ErrSheetLocked},
+		"submitted by a reviewer": {status: report.SheetStatusSubmitted, by: testReviewer, want: ErrNotOwner},
+	}
+
+	deleteRow := func(_ []string, rows [][]string) ([][]string, error) { return rows[1:], nil }
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			useTestStore(t)
+			saveTestSheet(t, tt.status)
+
+			_, rows, err := UpdateSheet(ctx, testSheetID, tt.by, deleteRow)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && len(rows) != 1 {
+				t.Errorf("got %d rows, want 1", len(rows))
+			}
+		})
+	}
+}
+
+func TestUpdateSheetAfterWithdraw(t *testing.T) {
+	ctx := context.Background()
+	useTestStore(t)
+	saveTestSheet(t, report.SheetStatusSubmitted)
+
+	edit := func(_ []string, rows [][]string) ([][]string, error) { return rows[:1], nil }
+	if _, _, err := UpdateSheet(ctx, testSheetID, testUploader, edit); !errors.Is(err, ErrSheetLocked) {
+		t.Fatalf("edit while submitted: got %v, want ErrSheetLocked", err)
+	}
+
+	if _, err := TransitionSheet(ctx, testSheetID, report.SheetStatusDraft, testUploader, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := UpdateSheet(ctx, testSheetID, testUploader, edit); err != nil {
+		t.Fatalf("edit after withdrawing: %v", err)
+	}
+	if _, err := TransitionSheet(ctx, testSheetID, report.SheetStatusSubmitted, testUploader, ""); err != nil {
+		t.Fatalf("submit after editing: %v", err)
+	}
+}
+
+func TestUpdateLegacySheet(t *testing.T) {
+	ctx := context.Background()
+	useTestStore(t)
+
+	// uploaded before metadata was stored
+	saveTestRevisions(t, [][]string{CSVHeader, rowA, rowB})
+
+	meta, err := ReadMeta(ctx, testSheetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Status != report.SheetStatusDraft {
+		t.Fatalf("legacy sheet is %s, want draft", meta.Status)
+	}
+
+	// the first edit claims the sheet
+	edit := func(_ []string, rows [][]string) ([][]string, error) { return rows[:1], nil }
+	if _, _, err := UpdateSheet(ctx, testSheetID, testUploader, edit); err != nil {
+		t.Fatalf("edit legacy sheet: %v", err)
+	}
+	if meta, err := ReadMeta(ctx, testSheetID); err != nil || meta.UploadedBy != testUploader {
+		t.Fatalf("got %+v, %v, want the sheet owned by the first editor", meta, err)
+	}
+
+	if _, _, err := UpdateSheet(ctx, testSheetID, testReviewer, edit); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("edit by someone else: got %v, want ErrNotOwner", err)
+	}
+	if _, err := TransitionSheet(ctx, testSheetID, report.SheetStatusSubmitted, testReviewer, ""); !errors.Is(err, ErrNotSubmitter) {
+		t.Errorf("submit by someone else: got %v, want ErrNotSubmitter", err)
+	}
+	if _, err := TransitionSheet(ctx, testSheetID, report.SheetStatusSubmitted, testUploader, ""); !errors.Is(err, ErrNoReceivers) {
+		t.Errorf("submit without receivers: got %v, want ErrNoReceivers", err)
+	}
+}
+
+func TestSubmitLegacySheet(t *testing.T) {
+	ctx := context.Background()
+	useTestStore(t)
+	saveTestRevisions(t, [][]string{CSVHeader, rowA, rowB})
+
+	// submitting claims the sheet too, but it needs receivers first
+	if _, err := TransitionSheet(ctx, testSheetID, report.SheetStatusSubmitted, testUploader, ""); !errors.Is(err, ErrNoReceivers) {
+		t.Fatalf("got %v, want ErrNoReceivers", err)
+	}
+	if meta, err := ReadMeta(ctx, testSheetID); err != nil || meta.UploadedBy != "" {
+		t.Fatalf("refused submit claimed the sheet: %+v, %v", meta, err)
+	}
+
+	_, err := UploadSheet(ctx, SheetUpload{
+		Meta:   report.SheetMeta{ID: testSheetID, UploadedBy: testUploader, Receivers: []string{testReviewer}},
+		Header: CSVHeader,
+		Rows:   [][]string{rowA},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UploadSheet(ctx, SheetUpload{
+		Meta:   report.SheetMeta{ID: testSheetID, UploadedBy: testReviewer, Receivers: []string{testUploader}},
+		Header: CSVHeader,
+		Rows:   [][]string{rowB},
+	}); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("overwrite by someone else: got %v, want ErrNotOwner", err)
+	}
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiscordNotifier posts embeds to a Discord channel webhook
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (d *DiscordNotifier) Name() string {
+	return "discord"
+}
+
+func (d *DiscordNotifier) Notify(ctx context.Context, message MessageBody) error {
+	description := fmt.Sprintf("FileName: **%s**", message.FileName)
+	if message.Note != "" {
+		description += "\n" + message.Note
+	}
+
+	payload := map[string]any{
+		"embeds": []map[string]any{
+			{
+				"title":       messageTitle(message),
+				"url":         message.Url,
+				"description": description,
+				"color":       0x3C82F9,
+			},
+		},
+	}
+
+	if err := postJSON(ctx, d.WebhookURL, payload, nil); err != nil {
+		return fmt.Errorf("failed to send message to Discord: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// ListSheets returns one page of the uploaded sheets matching `query`. Sheets
+// are filtered and sorted on their metadata; a text search then reads the
+// sheets themselves only until the page is filled, so it can't tell the
+// Total, only whether there is More
+func ListSheets(ctx context.Context, query types.SheetListQuery) (*types.SheetList, error) {
+	// current sheets live at the root, revisions and metadata below it
+	objects, err := Store.ListDir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []report.SheetMeta{}
+	for _, obj := range objects {
+		if path.Ext(obj.Key) != ".csv" {
+			continue
+		}
+
+		// one unreadable sheet shouldn't hide all the others
+		meta, err := ReadMeta(ctx, strings.TrimSuffix(obj.Key, ".csv"))
+		if err != nil {
+			log.Printf("list: skipping %s: %v", obj.Key, err)
+			continue
+		}
+
+		if matchMeta(meta, query) {
+			candidates = append(candidates, *meta)
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b report.SheetMeta) int {
+		var c int
+		switch query.Sort {
+		case "repo":
+			c = cmp.Compare(strings.ToLower(a.Repo), strings.ToLower(b.Repo))
+		case "uploader":
+			c = cmp.Compare(strings.ToLower(a.UploadedBy), strings.ToLower(b.UploadedBy))
+		case "id":
+			c = cmp.Compare(a.ID, b.ID)
+		default:
+			c = a.UploadedAt.Compare(b.UploadedAt)
+		}
+		if query.Desc {
+			return -c
+		}
+		return c
+	})
+
+	list := &types.SheetList{Page: query.Page, Limit: query.Limit}
+	start := (query.Page - 1) * query.Limit
+	end := start + query.Limit
+
+	if query.Text == "" {
+		total := len(candidates)
+		list.Total = &total
+		list.Items = candidates[min(start, len(candidates)):min(end, len(candidates))]
+		list.More = end < len(candidates)
+		return list, nil
+	}
+
+	// stop at the first match past the page
+	items := []report.SheetMeta{}
+	for _, meta := range candidates {
+		if len(items) > end {
+			break
+		}
+
+		ok, err := matchText(ctx, &meta, query.Text)
+		if err != nil {
+			log.Printf("list: skipping %s: %v", meta.ID, err)
+			continue
+		}
+		if ok {
+			items = append(items, meta)
+		}
+	}
+	if len(items) > end {
+		list.More = true
+		items = items[:end]
+	}
+	list.Items = items[min(start, len(items)):]
+
+	return list, nil
+}
+
+// matchMeta tells whether the metadata of a sheet passes the filters of
+// `query`, all but the text search
+func matchMeta(meta *report.SheetMeta, query types.SheetListQuery) bool {
+	if query.Repo != "" && !strings.EqualFold(meta.Repo, query.Repo) {
+		return false
+	}
+	if query.Uploader != "" && !containsFold(meta.UploadedBy, query.Uploader) {
+		return false
+	}
+	if query.Status != "" && !strings.EqualFold(meta.Status, query.Status) {
+		return false
+	}
+	if !query.From.IsZero() && meta.UploadedAt.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && meta.UploadedAt.After(query.To) {
+		return false
+	}
+	return true
+}
+
+// matchText searches the description of a sheet, then its commits
+func matchText(ctx context.Context, meta *report.SheetMeta, text string) (bool, error) {
+	if containsFold(meta.Description, text) {
+		return true, nil
+	}
+
+	// only now read the sheet itself
+	header, rows, err := ReadSheet(ctx, meta.ID)
+	if err != nil {
+		return false, err
+	}
+
+	col := slices.Index(header, "Description")
+	if col < 0 {
+		return false, nil
+	}
+
+	return slices.ContainsFunc(rows, func(row []string) bool {
+		return col < len(row) && containsFold(row[col], text)
+	}), nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
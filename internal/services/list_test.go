@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/types"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// saveListSheets stores five sheets uploaded an hour apart, sheet-1 first:
+// odd ones by jane and submitted, even ones by john and approved. The
+// commits of sheets 2, 3 and 5 mention the login.
+func saveListSheets(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	uploaded := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	for n := 1; n <= 5; n++ {
+		id := "sheet-" + strconv.Itoa(n)
+
+		meta := report.SheetMeta{
+			ID:         id,
+			Repo:       "sheet-happens",
+			UploadedBy: "jane.doe@webpoint.io",
+			UploadedAt: uploaded.Add(time.Duration(n) * time.Hour),
+			Status:     report.SheetStatusSubmitted,
+		}
+		if n%2 == 0 {
+			meta.UploadedBy, meta.Status = "john@webpoint.io", report.SheetStatusApproved
+		}
+
+		row := slices.Clone(rowC)
+		if n == 2 || n == 3 || n == 5 {
+			row = slices.Clone(rowA)
+		}
+
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write(CSVHeader)
+		_ = w.Write(row)
+		w.Flush()
+
+		if err := Store.Put(ctx, sheetKey(id), buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveMeta(ctx, meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListSheets(t *testing.T) {
+	useTestStore(t)
+	saveListSheets(t)
+
+	tests := map[string]struct {
+		query     types.SheetListQuery
+		want      []string
+		wantTotal int // -1 = left out
+		wantMore  bool
+	}{
+		"every sheet":         {query: types.SheetListQuery{Desc: true}, want: []string{"sheet-5", "sheet-4", "sheet-3", "sheet-2", "sheet-1"}, wantTotal: 5},
+		"oldest first":        {query: types.SheetListQuery{Desc: false}, want: []string{"sheet-1", "sheet-2", "sheet-3", "sheet-4", "sheet-5"}, wantTotal: 5},
+		"uploader":            {query: types.SheetListQuery{Desc: true, Uploader: "JOHN@"}, want: []string{"sheet-4", "sheet-2"}, wantTotal: 2},
+		"status":              {query: types.SheetListQuery{Desc: true, Status: report.SheetStatusSubmitted}, want: []string{"sheet-5", "sheet-3", "sheet-1"}, wantTotal: 3},
+		"uploader and status": {query: types.SheetListQuery{Desc: true, Uploader: "jane", Status: report.SheetStatusApproved}, want: []string{}, wantTotal: 0},
+		"first page":          {query: types.SheetListQuery{Desc: true, Limit: 2}, want: []string{"sheet-5", "sheet-4"}, wantTotal: 5, wantMore: true},
+		"last page":           {query: types.SheetListQuery{Desc: true, Page: 3, Limit: 2}, want: []string{"sheet-1"}, wantTotal: 5},
+		"past the last page":  {query: types.SheetListQuery{Desc: true, Page: 4, Limit: 2}, want: []string{}, wantTotal: 5},
+		"search":              {query: types.SheetListQuery{Desc: true, Text: "LOGIN"}, want: []string{"sheet-5", "sheet-3", "sheet-2"}, wantTotal: -1},
+		"search first page":   {query: types.SheetListQuery{Desc: true, Text: "login", Limit: 2}, want: []string{"sheet-5", "sheet-3"}, wantTotal: -1, wantMore: true},
+		"search last page":    {query: types.SheetListQuery{Desc: true, Text: "login", Page: 2, Limit: 2}, want: []string{"sheet-2"}, wantTotal: -1},
+		"search and status":   {query: types.SheetListQuery{Desc: true, Text: "login", Status: report.SheetStatusApproved}, want: []string{"sheet-2"}, wantTotal: -1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query := tt.query
+			query.Page = max(query.Page, 1)
+			if query.Limit == 0 {
+				query.Limit = 20
+			}
+
+			list, err := ListSheets(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, meta := range list.Items {
+				got = append(got, meta.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+			if list.More != tt.wantMore {
+				t.Errorf("more = %v, want %v", list.More, tt.wantMore)
+			}
+			switch {
+			case tt.wantTotal < 0 && list.Total != nil:
+				t.Errorf("total = %d, want it left out", *list.Total)
+			case tt.wantTotal >= 0 && (list.Total == nil || *list.Total != tt.wantTotal):
+				t.Errorf("total = %v, want %d", list.Total, tt.wantTotal)
+			}
+		})
+	}
+}
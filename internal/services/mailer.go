@@ -2,10 +2,12 @@ package services
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/smtp"
 	"os"
 	"strings"
@@ -14,6 +16,9 @@ import (
 	"github.com/webpointsolutions/sheet-happens/internal/config"
 )
 
+// smtpTimeout bounds a whole SMTP exchange, the outbox lease must outlast it
+const smtpTimeout = 2 * time.Minute
+
 func SendEmailWithAttachment(
 	params EmailRequestParams,
 ) error {
@@ -24,7 +29,7 @@ func SendEmailWithAttachment(
 
 	html, err := getHTMLTemplate(params.EmailTemplate, params.TemplateParams)
 	if err != nil {
-		return nil
+		return err
 	}
 	params.html = html
 	params.From = config.Env.SMTPUsername
@@ -43,7 +48,7 @@ func SendEmailWithAttachment(
 		return err
 	}
 
-	err = smtp.SendMail(address, auth, params.From, recipients, emailTmpl)
+	err = sendMail(address, smtpHost, auth, params.From, recipients, emailTmpl)
 	if err != nil {
 		log.Println("Unable send mail to", err.Error())
 		return err
@@ -52,22 +57,72 @@ func SendEmailWithAttachment(
 	return nil
 }
 
-func smtpWithAttachmentEmailSender(params EmailRequestParams) ([]byte, error) {
-	env := config.Env
-	senderEmail := env.SMTPUsername
+// sendMail is smtp.SendMail within smtpTimeout, a stalled server would
+// otherwise hold the outbox worker forever
+func sendMail(address, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := net.DialTimeout("tcp", address, smtpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok && auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
 
-	if params.From != "" {
-		senderEmail = params.From
+func smtpWithAttachmentEmailSender(params EmailRequestParams) ([]byte, error) {
+	senderEmail := params.From
+	if senderEmail == "" {
+		senderEmail = config.Env.SMTPUsername
 	}
 	// Prepare recipients
 
 	// Email Headers
 	var msg bytes.Buffer
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", senderEmail))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", params.To))
-	msg.WriteString(fmt.Sprintf("Cc: %s\r\n", strings.Join(params.CC, ", ")))
-	msg.WriteString(fmt.Sprintf("Bcc: %s\r\n", strings.Join(params.BCC, ", ")))
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", params.Subject))
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", headerValue(senderEmail)))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", headerValue(params.To)))
+	msg.WriteString(fmt.Sprintf("Cc: %s\r\n", headerValue(strings.Join(params.CC, ", "))))
+	msg.WriteString(fmt.Sprintf("Bcc: %s\r\n", headerValue(strings.Join(params.BCC, ", "))))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", headerValue(params.Subject)))
 	msg.WriteString("MIME-Version: 1.0\r\n")
 
 	boundary := "MIMEBoundary"
@@ -82,9 +137,10 @@ func smtpWithAttachmentEmailSender(params EmailRequestParams) ([]byte, error) {
 	for _, attachment := range params.EmailAttachment {
 		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
 		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString(fmt.Sprintf(`Content-Type: %s; name="%s"`+"\r\n", attachment.ContentType, attachment.FileName))
+		fileName := headerValue(attachment.FileName)
+		msg.WriteString(fmt.Sprintf(`Content-Type: %s; name="%s"`+"\r\n", headerValue(attachment.ContentType), fileName))
 		msg.WriteString("Content-Transfer-Encoding: base64\r\n")
-		msg.WriteString(fmt.Sprintf(`Content-Disposition: attachment; filename="%s"`+"\r\n\r\n", attachment.FileName))
+		msg.WriteString(fmt.Sprintf(`Content-Disposition: attachment; filename="%s"`+"\r\n\r\n", fileName))
 		msg.WriteString(encoded + "\r\n\r\n")
 	}
 
@@ -93,6 +149,14 @@ func smtpWithAttachmentEmailSender(params EmailRequestParams) ([]byte, error) {
 	return msg.Bytes(), nil
 }
 
+// headerValue drops the line breaks a value would need to start another
+// header or the body of the message
+func headerValue(value string) string {
+	return headerBreaks.Replace(value)
+}
+
+var headerBreaks = strings.NewReplacer("\r", "", "\n", "")
+
 type EmailRequestParams struct {
 	To   string
 	From string
@@ -135,12 +199,12 @@ func getHTMLTemplate(templateName string, data any) (string, error) {
 		},
 	}
 
-	htmlTemplate, err := template.New("email.html").Funcs(funcMap).Parse(string(htmlData))
+	htmlTemplate, err := template.New(templateName).Funcs(funcMap).Parse(string(htmlData))
 	if err != nil {
 		return "", err
 	}
 
-	err = htmlTemplate.ExecuteTemplate(&templateBuffer, "email.html", data)
+	err = htmlTemplate.ExecuteTemplate(&templateBuffer, templateName, data)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmailHeadersDropLineBreaks(t *testing.T) {
+	msg, err := smtpWithAttachmentEmailSender(EmailRequestParams{
+		From:    "sheets@webpoint.io",
+		To:      "lead@webpoint.io",
+		Subject: "Work log submitted: repo\r\nBcc: everyone@example.com",
+		EmailAttachment: []EmailAttachment{
+			{FileName: "log.csv\"\r\nX-Injected: yes", ContentType: "text/csv", Data: []byte("Date\n")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(msg), "\r\n") {
+		if strings.HasPrefix(line, "Bcc: everyone") || strings.HasPrefix(line, "X-Injected") {
+			t.Errorf("injected header %q", line)
+		}
+	}
+	if !strings.Contains(string(msg), "Subject: Work log submitted: repoBcc: everyone@example.com\r\n") {
+		t.Errorf("subject lost its text:\n%s", msg)
+	}
+}
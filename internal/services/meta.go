@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+	"github.com/webpointsolutions/sheet-happens/internal/utils"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+var ErrInvalidMeta = errors.New("invalid sheet metadata")
+
+// metadata of a sheet is kept as meta/{id}.json
+func metaKey(id string) string {
+	return "meta/" + id + ".json"
+}
+
+func SaveMeta(ctx context.Context, meta report.SheetMeta) error {
+	if !ValidSheetID(meta.ID) {
+		return ErrSheetNotFound
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return Store.Put(ctx, metaKey(meta.ID), data)
+}
+
+// ReadMeta returns the metadata of a sheet. Sheets uploaded before metadata
+// was stored get what can be derived from their file name. Sheets from before
+// the approval workflow are drafts. Without an uploader they belong to the
+// first one to write them, who uploads them again to pick receivers.
+func ReadMeta(ctx context.Context, id string) (*report.SheetMeta, error) {
+	if !ValidSheetID(id) {
+		return nil, ErrSheetNotFound
+	}
+
+	data, err := Store.Get(ctx, metaKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		obj, err := Store.Stat(ctx, sheetKey(id))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, ErrSheetNotFound
+			}
+			return nil, err
+		}
+
+		return &report.SheetMeta{
+			ID:         id,
+			FileName:   obj.Key,
+			Repo:       utils.GetRepoNameFromFileName(obj.Key),
+			UploadedAt: obj.ModTime,
+			Receivers:  []string{},
+			Status:     report.SheetStatusDraft,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta report.SheetMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	if meta.Status == "" {
+		meta.Status = report.SheetStatusDraft
+	}
+
+	return &meta, nil
+}
+
+// ValidateMeta checks the fields a client sends along with a sheet, they end
+// up in email subjects and notifications so control characters are refused
+func ValidateMeta(meta report.SheetMeta) error {
+	fields := []struct{ name, value string }{
+		{"file_name", meta.FileName},
+		{"repo", meta.Repo},
+		{"branch", meta.Branch},
+		{"period", meta.Period},
+		{"generated_by", meta.GeneratedBy},
+		{"description", meta.Description},
+	}
+	for _, receiver := range meta.Receivers {
+		fields = append(fields, struct{ name, value string }{"receivers", receiver})
+	}
+	for _, field := range fields {
+		if strings.ContainsFunc(field.value, unicode.IsControl) {
+			return fmt.Errorf("%w: %s contains control characters", ErrInvalidMeta, field.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+)
+
+// Notifier delivers a message to a chat service or webhook
+type Notifier interface {
+	// Name identifies the notifier in queued outbox jobs
+	Name() string
+	Notify(ctx context.Context, message MessageBody) error
+}
+
+// Notifiers receive every sheet notification, they are set once on startup
+// from NewNotifiers
+var Notifiers []Notifier
+
+var notifierClient = &http.Client{Timeout: 15 * time.Second}
+
+// NewNotifiers returns a notifier for every webhook configured in config.Env
+func NewNotifiers() []Notifier {
+	var notifiers []Notifier
+
+	if url := config.Env.SlackWebhookURL; url != "" {
+		notifiers = append(notifiers, &SlackNotifier{WebhookURL: url})
+	}
+	if url := config.Env.TeamsWebhookURL; url != "" {
+		notifiers = append(notifiers, &TeamsNotifier{WebhookURL: url})
+	}
+	if url := config.Env.DiscordWebhookURL; url != "" {
+		notifiers = append(notifiers, &DiscordNotifier{WebhookURL: url})
+	}
+	if url := config.Env.NotifyWebhookURL; url != "" {
+		notifiers = append(notifiers, &WebhookNotifier{URL: url, Secret: config.Env.NotifyWebhookSecret})
+	}
+
+	return notifiers
+}
+
+func notifierByName(name string) (Notifier, error) {
+	for _, n := range Notifiers {
+		if n.Name() == name {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("notifier %q is not configured", name)
+}
+
+// postJSON sends `payload` to `url` and fails on any non 2xx response
+func postJSON(ctx context.Context, url string, payload any, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal message payload: %w", err)
+	}
+
+	return postBody(ctx, url, body, headers)
+}
+
+func postBody(ctx context.Context, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := notifierClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("non-OK HTTP status: %s %s", resp.Status, respBody)
+	}
+
+	return nil
+}
+
+// messageTitle strips the Slack markup from the title of `message`
+func messageTitle(message MessageBody) string {
+	if message.Title == "" {
+		return "Notice: Work Log Sent"
+	}
+
+	title := message.Title
+	for _, markup := range []string{"*", "󱝏 "} {
+		title = strings.ReplaceAll(title, markup, "")
+	}
+	return strings.TrimSpace(title)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testMessage = MessageBody{
+	FileName: "1725815494_sheet-happens_6448_log_final.csv",
+	Url:      "https://sheets.example.com/1725815494_sheet-happens_6448_log_final",
+	Title:    "*󱝏 Notice: Work Log Approved*",
+	Note:     "Reviewer: *lead@example.com*",
+}
+
+// received is a request the test server got
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts a server answering `status` and returns what it received
+func newReceiver(t *testing.T, status int) (*httptest.Server, chan received) {
+	t.Helper()
+
+	requests := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with content type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		requests <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func TestNotifierPayloads(t *testing.T) {
+	tests := map[string]struct {
+		notifier func(url string) Notifier
+		check    func(t *testing.T, payload map[string]any)
+	}{
+		"slack": {
+			notifier: func(url string) Notifier { return &SlackNotifier{WebhookURL: url} },
+			check: func(t *testing.T, payload map[string]any) {
+				blocks := payload["blocks"].([]any)
+				if len(blocks) != 2 {
+					t.Fatalf("got %d blocks, want 2", len(blocks))
+				}
+				title := blocks[0].(map[string]any)["text"].(map[string]any)
+				text := blocks[1].(map[string]any)["text"].(map[string]any)
+				if title["type"] != "mrkdwn" || title["text"] != testMessage.Title {
+					t.Errorf("title block = %v", title)
+				}
+				want := "FileName: *" + testMessage.FileName + "*\nURL: *" + testMessage.Url + "*\n" + testMessage.Note
+				if text["text"] != want {
+					t.Errorf("text block = %q, want %q", text["text"], want)
+				}
+			},
+		},
+		"teams": {
+			notifier: func(url string) Notifier { return &TeamsNotifier{WebhookURL: url} },
+			check: func(t *testing.T, payload map[string]any) {
+				if payload["type"] != "message" {
+					t.Errorf("type = %v, want message", payload["type"])
+				}
+				attachment := payload["attachments"].([]any)[0].(map[string]any)
+				if attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+					t.Errorf("contentType = %v", attachment["contentType"])
+				}
+				card := attachment["content"].(map[string]any)
+				body := card["body"].([]any)
+				if len(body) != 3 {
+					t.Fatalf("got %d text blocks, want 3", len(body))
+				}
+				if text := body[0].(map[string]any)["text"]; text != "Notice: Work Log Approved" {
+					t.Errorf("title = %q, want the title without markup", text)
+				}
+				if text := body[2].(map[string]any)["text"]; text != testMessage.Note {
+					t.Errorf("note = %q", text)
+				}
+				action := card["actions"].([]any)[0].(map[string]any)
+				if action["type"] != "Action.OpenUrl" || action["url"] != testMessage.Url {
+					t.Errorf("action = %v", action)
+				}
+			},
+		},
+		"discord": {
+			notifier: func(url string) Notifier { return &DiscordNotifier{WebhookURL: url} },
+			check: func(t *testing.T, payload map[string]any) {
+				embed := payload["embeds"].([]any)[0].(map[string]any)
+				if embed["title"] != "Notice: Work Log Approved" || embed["url"] != testMessage.Url {
+					t.Errorf("embed = %v", embed)
+				}
+				want := "FileName: **" + testMessage.FileName + "**\n" + testMessage.Note
+				if embed["description"] != want {
+					t.Errorf("description = %q, want %q", embed["description"], want)
+				}
+			},
+		},
+		"webhook": {
+			notifier: func(url string) Notifier { return &WebhookNotifier{URL: url} },
+			check: func(t *testing.T, payload map[string]any) {
+				want := map[string]any{
+					"title":     "Notice: Work Log Approved",
+					"file_name": testMessage.FileName,
+					"url":       testMessage.Url,
+					"note":      testMessage.Note,
+				}
+				for key, value := range want {
+					if payload[key] != value {
+						t.Errorf("%s = %v, want %v", key, payload[key], value)
+					}
+				}
+				if _, ok := payload["sent_at"].(string); !ok {
+					t.Errorf("sent_at is missing")
+				}
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := newReceiver(t, http.StatusOK)
+
+			n := tt.notifier(srv.URL)
+			if n.Name() != name {
+				t.Errorf("Name() = %q, want %q", n.Name(), name)
+			}
+			if err := n.Notify(context.Background(), testMessage); err != nil {
+				t.Fatal(err)
+			}
+
+			req := <-requests
+			var payload map[string]any
+			if err := json.Unmarshal(req.body, &payload); err != nil {
+				t.Fatalf("invalid JSON %q: %v", req.body, err)
+			}
+			tt.check(t, payload)
+
+			if sig := req.header.Get(SignatureHeader); sig != "" {
+				t.Errorf("unsigned message got %s: %s", SignatureHeader, sig)
+			}
+		})
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	srv, requests := newReceiver(t, http.StatusNoContent)
+
+	n := &WebhookNotifier{URL: srv.URL, Secret: "s3cret"}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-requests
+	sig := req.header.Get(SignatureHeader)
+	if sig != "sha256="+SignWebhookBody("s3cret", req.body) {
+		t.Fatalf("%s = %q doesn't sign the body", SignatureHeader, sig)
+	}
+
+	// the well known HMAC-SHA256 example, receivers compute the same digest
+	if got := SignWebhookBody("key", []byte("The quick brown fox jumps over the lazy dog")); got != "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Fatalf("SignWebhookBody = %s", got)
+	}
+}
+
+func TestNotifierErrors(t *testing.T) {
+	notifiers := map[string]func(url string) Notifier{
+		"slack":   func(url string) Notifier { return &SlackNotifier{WebhookURL: url} },
+		"teams":   func(url string) Notifier { return &TeamsNotifier{WebhookURL: url} },
+		"discord": func(url string) Notifier { return &DiscordNotifier{WebhookURL: url} },
+		"webhook": func(url string) Notifier { return &WebhookNotifier{URL: url} },
+	}
+
+	for name, notifier := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := newReceiver(t, http.StatusInternalServerError)
+			err := notifier(srv.URL).Notify(context.Background(), testMessage)
+			if err == nil || !strings.Contains(err.Error(), "500") {
+				t.Fatalf("got %v, want the 500 status", err)
+			}
+			<-requests
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := notifier(srv.URL).Notify(ctx, testMessage); !errors.Is(err, context.Canceled) {
+				t.Fatalf("canceled context: got %v, want context.Canceled", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/config"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// NotifySheetSubmitted queues an email of the sheet to its reviewers and a Slack post
+func NotifySheetSubmitted(ctx context.Context, meta report.SheetMeta) error {
+	if len(meta.Receivers) == 0 {
+		return fmt.Errorf("sheet %s has no receivers", meta.ID)
+	}
+
+	data := map[string]any{
+		"Name":   "Aashutosh",
+		"Link":   GetFileFrontendUrl(meta.ID),
+		"Period": sheetPeriod(meta),
+	}
+
+	subject := fmt.Sprintf("TimeSheet received for %s, %s", meta.Repo, formatNotificationTime(time.Now()))
+	if meta.Status == report.SheetStatusResubmitted {
+		subject = fmt.Sprintf("TimeSheet resubmitted for %s, %s", meta.Repo, formatNotificationTime(time.Now()))
+	}
+
+	emailParams := EmailRequestParams{
+		To:             meta.Receivers[0],
+		CC:             meta.Receivers[1:],
+		EmailTemplate:  "email",
+		Subject:        subject,
+		TemplateParams: data,
+	}
+
+	if err := EnqueueEmail(ctx, emailParams, meta.ID); err != nil {
+		return err
+	}
+
+	var note string
+	if period := sheetPeriod(meta); period != "" {
+		note = fmt.Sprintf("Commits: *%s*", period)
+	}
+
+	return EnqueueNotification(ctx, MessageBody{
+		FileName: meta.ID,
+		Url:      GetFileFrontendUrl(meta.ID),
+		Note:     note,
+	})
+}
+
+// NotifyStatusChange queues a notice to the submitter that a reviewer approved or rejected their sheet
+func NotifyStatusChange(ctx context.Context, meta report.SheetMeta) error {
+	if meta.UploadedBy == "" || len(meta.History) == 0 {
+		return fmt.Errorf("sheet %s has no submitter to notify", meta.ID)
+	}
+	change := meta.History[len(meta.History)-1]
+
+	data := map[string]any{
+		"Repo":     meta.Repo,
+		"Status":   change.Status,
+		"Reviewer": change.By,
+		"Reason":   change.Reason,
+		"Link":     GetFileFrontendUrl(meta.ID),
+	}
+
+	emailParams := EmailRequestParams{
+		To:             meta.UploadedBy,
+		EmailTemplate:  "status",
+		Subject:        fmt.Sprintf("TimeSheet %s for %s, %s", change.Status, meta.Repo, formatNotificationTime(change.At)),
+		TemplateParams: data,
+	}
+
+	if err := EnqueueEmail(ctx, emailParams, ""); err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("Reviewer: *%s*", change.By)
+	if change.Reason != "" {
+		note += fmt.Sprintf("\nReason: %s", change.Reason)
+	}
+
+	return EnqueueNotification(ctx, MessageBody{
+		FileName: meta.ID,
+		Url:      GetFileFrontendUrl(meta.ID),
+		Title:    fmt.Sprintf("*󱝏 Notice: Work Log %s*", strings.ToUpper(change.Status[:1])+change.Status[1:]),
+		Note:     note,
+	})
+}
+
+// sheetPeriod describes the commit range of the sheet, empty when it covers all history
+func sheetPeriod(meta report.SheetMeta) string {
+	if r := report.MetaRange(meta); !r.IsZero() {
+		return r.String()
+	}
+	return ""
+}
+
+// GetFileFrontendUrl links to the sheet in the dashboard
+func GetFileFrontendUrl(filename string) string {
+	return config.Env.FrontHost + "/dashboard/" + filename
+}
+
+// formatNotificationTime formats `t` in Kathmandu time (UTC +5:45), eg: "March 25 2024, 5:45 PM"
+func formatNotificationTime(t time.Time) string {
+	location, err := time.LoadLocation("Asia/Kathmandu")
+	if err != nil {
+		location = time.FixedZone("NPT", 5*60*60+45*60)
+	}
+	return t.In(location).Format("January 2 2006, 3:04 PM")
+}
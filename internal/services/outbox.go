@@ -0,0 +1,472 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+// Notifications are written to the outbox first and delivered by a worker
+// pool, so a failing SMTP server or a restart doesn't lose them. Jobs live in
+// the storage backend as outbox/{id}.json and are deleted once delivered.
+// A worker claims a job by creating outbox/claims/{id}/{version}, so replicas
+// sharing the storage never deliver the same version of a job twice.
+const (
+	JobPending    = "pending"
+	JobProcessing = "processing"
+	JobDead       = "dead"
+
+	jobKindEmail  = "email"
+	jobKindNotify = "notify"
+	jobKindSlack  = "slack" // queued before notifiers existed
+
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+	outboxPoll        = 5 * time.Second
+
+	outboxCorruptPrefix = "outbox/corrupt/"
+
+	// a job left in processing this long was owned by a worker that died.
+	// It outlasts smtpTimeout and the notifier client timeout, so a slow
+	// delivery is never claimed, and sent, a second time
+	outboxLease = 5 * smtpTimeout
+)
+
+var (
+	ErrJobNotFound = errors.New("outbox job not found")
+	ErrJobNotDead  = errors.New("only dead jobs can be retried")
+)
+
+type OutboxJob struct {
+	ID            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// notifyJob is queued once per notifier, so one failing service doesn't
+// resend the message to the others
+type notifyJob struct {
+	Notifier string      `json:"notifier"`
+	Message  MessageBody `json:"message"`
+}
+
+type emailJob struct {
+	Params      EmailRequestParams `json:"params"`
+	AttachSheet string             `json:"attach_sheet,omitempty"` // sheet id read at delivery time
+}
+
+var (
+	outboxMu   sync.Mutex
+	outboxWake = make(chan struct{}, 1)
+)
+
+func outboxKey(id string) string {
+	return "outbox/" + id + ".json"
+}
+
+// every write of a job changes UpdatedAt, so it versions the job
+func claimKey(job OutboxJob) string {
+	return fmt.Sprintf("outbox/claims/%s/%d", job.ID, job.UpdatedAt.UnixNano())
+}
+
+// EnqueueEmail queues an email, `attachSheet` (optional) attaches the current
+// content of that sheet when the email is sent
+func EnqueueEmail(ctx context.Context, params EmailRequestParams, attachSheet string) error {
+	return enqueue(ctx, jobKindEmail, emailJob{Params: params, AttachSheet: attachSheet})
+}
+
+// EnqueueNotification queues `message` for every configured notifier
+func EnqueueNotification(ctx context.Context, message MessageBody) error {
+	var errs []error
+	for _, n := range Notifiers {
+		errs = append(errs, enqueue(ctx, jobKindNotify, notifyJob{Notifier: n.Name(), Message: message}))
+	}
+	return errors.Join(errs...)
+}
+
+func enqueue(ctx context.Context, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job := OutboxJob{
+		ID:            fmt.Sprintf("%d-%s", now.UnixNano(), hex.EncodeToString(id)),
+		Kind:          kind,
+		Payload:       data,
+		Status:        JobPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := putJob(ctx, &job); err != nil {
+		return err
+	}
+
+	// wakes the dispatcher instead of waiting for the next poll
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// StartOutbox delivers queued jobs with `workers` goroutines until `ctx` is
+// done. Replicas sharing the storage can all run workers. Only as many jobs
+// are claimed as there are idle workers, so a claimed job is delivered right
+// away and well within outboxLease.
+func StartOutbox(ctx context.Context, workers int) {
+	if workers <= 0 {
+		return
+	}
+
+	// a token for every idle worker, only the dispatcher takes them
+	idle := make(chan struct{}, workers)
+	jobs := make(chan OutboxJob, workers)
+	for range workers {
+		idle <- struct{}{}
+		go func() {
+			for job := range jobs {
+				deliverJob(ctx, job)
+				idle <- struct{}{}
+
+				// more jobs may have been due than there were idle workers
+				select {
+				case outboxWake <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+
+		ticker := time.NewTicker(outboxPoll)
+		defer ticker.Stop()
+
+		for {
+			if free := len(idle); free > 0 {
+				due, err := claimDueJobs(ctx, free)
+				if err != nil {
+					log.Println("outbox:", err)
+				}
+
+				for _, job := range due {
+					<-idle
+					jobs <- job
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-outboxWake:
+			}
+		}
+	}()
+}
+
+// claimDueJobs marks up to `limit` jobs that are due as processing and
+// returns them. outboxMu only orders the workers of this process, the claim
+// keys order the replicas.
+func claimDueJobs(ctx context.Context, limit int) ([]OutboxJob, error) {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	all, err := listJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var due []OutboxJob
+	for _, job := range all {
+		if len(due) == limit {
+			break
+		}
+
+		stale := job.Status == JobProcessing && now.Sub(job.UpdatedAt) > outboxLease
+		if !stale && (job.Status != JobPending || job.NextAttemptAt.After(now)) {
+			continue
+		}
+
+		claimed, err := claimJob(ctx, job, now)
+		if errors.Is(err, storage.ErrExists) {
+			continue // another replica got it first
+		} else if err != nil {
+			return due, err
+		}
+		due = append(due, *claimed)
+	}
+
+	return due, nil
+}
+
+// claimJob claims the version of `job` that was listed and marks it as
+// processing. It returns storage.ErrExists when another replica claimed that
+// version or the job changed since it was listed, whose claims may be gone.
+func claimJob(ctx context.Context, job OutboxJob, now time.Time) (*OutboxJob, error) {
+	claim := claimKey(job)
+	if err := Store.Create(ctx, claim, []byte(now.Format(time.RFC3339Nano))); err != nil {
+		return nil, err
+	}
+
+	current, err := getJob(ctx, job.ID)
+	if errors.Is(err, ErrJobNotFound) || err == nil && !current.UpdatedAt.Equal(job.UpdatedAt) {
+		_ = Store.Delete(ctx, claim)
+		return nil, storage.ErrExists
+	}
+	if err != nil {
+		_ = Store.Delete(ctx, claim)
+		return nil, err
+	}
+
+	current.Status = JobProcessing
+	current.UpdatedAt = now
+	if err := putJob(ctx, current); err != nil {
+		_ = Store.Delete(ctx, claim)
+		return nil, err
+	}
+	return current, nil
+}
+
+func deliverJob(ctx context.Context, job OutboxJob) {
+	err := runJob(ctx, job)
+
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	if err == nil {
+		if err := Store.Delete(ctx, outboxKey(job.ID)); err != nil {
+			log.Printf("outbox: could not remove delivered job %s: %v", job.ID, err)
+			return
+		}
+		deleteClaims(ctx, job.ID)
+		return
+	}
+
+	// the job is written as a new version below, claimJob refuses the
+	// versions these claims were for
+	defer deleteClaims(ctx, job.ID)
+
+	job.Attempts++
+	job.LastError = err.Error()
+	job.UpdatedAt = time.Now()
+
+	if job.Attempts >= outboxMaxAttempts {
+		job.Status = JobDead
+		log.Printf("outbox: %s job %s is dead after %d attempts: %v", job.Kind, job.ID, job.Attempts, err)
+	} else {
+		job.Status = JobPending
+		job.NextAttemptAt = job.UpdatedAt.Add(outboxBackoff(job.Attempts))
+		log.Printf("outbox: %s job %s failed, retrying at %s: %v", job.Kind, job.ID, job.NextAttemptAt.Format(time.DateTime), err)
+	}
+
+	if err := putJob(ctx, &job); err != nil {
+		log.Printf("outbox: could not update job %s: %v", job.ID, err)
+	}
+}
+
+// outboxBackoff doubles the wait after every failed attempt
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+func runJob(ctx context.Context, job OutboxJob) error {
+	switch job.Kind {
+	case jobKindEmail:
+		var payload emailJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		if payload.AttachSheet != "" {
+			data, err := ReadSheetData(ctx, payload.AttachSheet)
+			if err != nil {
+				return fmt.Errorf("could not read the attached sheet: %w", err)
+			}
+			payload.Params.EmailAttachment = append(payload.Params.EmailAttachment, EmailAttachment{
+				FileName:    payload.AttachSheet,
+				Data:        data,
+				ContentType: "application/octet-stream",
+			})
+		}
+
+		return SendEmailWithAttachment(payload.Params)
+
+	case jobKindNotify, jobKindSlack:
+		payload := notifyJob{Notifier: job.Kind}
+		if job.Kind == jobKindSlack {
+			if err := json.Unmarshal(job.Payload, &payload.Message); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		n, err := notifierByName(payload.Notifier)
+		if err != nil {
+			return err
+		}
+		return n.Notify(ctx, payload.Message)
+
+	default:
+		return fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+}
+
+// ListOutbox returns the queued jobs, filtered by `status` when set
+func ListOutbox(ctx context.Context, status string) ([]OutboxJob, error) {
+	jobs, err := listJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != "" {
+		jobs = slices.DeleteFunc(jobs, func(job OutboxJob) bool { return job.Status != status })
+	}
+	return jobs, nil
+}
+
+// RetryOutboxJob queues a dead job again right away with a fresh attempt
+// budget
+func RetryOutboxJob(ctx context.Context, id string) (*OutboxJob, error) {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	job, err := getJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobDead {
+		return nil, ErrJobNotDead
+	}
+
+	job.Status = JobPending
+	job.Attempts = 0
+	job.NextAttemptAt = time.Now()
+	job.UpdatedAt = job.NextAttemptAt
+
+	if err := putJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+func listJobs(ctx context.Context) ([]OutboxJob, error) {
+	objects, err := Store.ListDir(ctx, "outbox/")
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []OutboxJob{}
+	for _, obj := range objects {
+		data, err := Store.Get(ctx, obj.Key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue // delivered in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var job OutboxJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			buryJob(ctx, obj.Key, data, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// buryJob moves a job that can't be read to outbox/corrupt/, so it stops
+// failing every poll and is kept for a look by hand
+func buryJob(ctx context.Context, key string, data []byte, err error) {
+	log.Printf("outbox: moving unreadable job %s to %s: %v", key, outboxCorruptPrefix, err)
+
+	if err := Store.Put(ctx, outboxCorruptPrefix+path.Base(key), data); err != nil {
+		log.Printf("outbox: could not move %s: %v", key, err)
+		return
+	}
+	if err := Store.Delete(ctx, key); err != nil {
+		log.Printf("outbox: could not remove %s: %v", key, err)
+	}
+}
+
+func getJob(ctx context.Context, id string) (*OutboxJob, error) {
+	if !ValidSheetID(id) {
+		return nil, ErrJobNotFound
+	}
+
+	data, err := Store.Get(ctx, outboxKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job OutboxJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func putJob(ctx context.Context, job *OutboxJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return Store.Put(ctx, outboxKey(job.ID), data)
+}
+
+// deleteClaims removes the claims of a finished delivery as far as it can
+func deleteClaims(ctx context.Context, id string) {
+	objects, err := Store.List(ctx, "outbox/claims/"+id+"/")
+	if err != nil {
+		log.Printf("outbox: could not list the claims of job %s: %v", id, err)
+		return
+	}
+	for _, obj := range objects {
+		_ = Store.Delete(ctx, obj.Key)
+	}
+}
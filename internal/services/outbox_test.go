@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+// useTestStore points Store at a local backend in a temporary directory
+func useTestStore(t *testing.T) storage.Storage {
+	t.Helper()
+
+	s, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := Store
+	Store = s
+	t.Cleanup(func() { Store = previous })
+	return s
+}
+
+// putTestJob stores a job whose kind no worker knows, so delivering it fails
+func putTestJob(t *testing.T, job OutboxJob) OutboxJob {
+	t.Helper()
+
+	if job.Kind == "" {
+		job.Kind = "test"
+	}
+	job.Payload = []byte(`{}`)
+	job.UpdatedAt = job.UpdatedAt.Round(0)
+	if err := putJob(context.Background(), &job); err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// claimsOf returns the claim keys left for job `id`
+func claimsOf(t *testing.T, id string) []storage.Object {
+	t.Helper()
+
+	claims, err := Store.List(context.Background(), "outbox/claims/"+id+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestClaimDueJobs(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		job     OutboxJob
+		claimed bool
+	}{
+		"due":              {job: OutboxJob{Status: JobPending, NextAttemptAt: now.Add(-time.Second), UpdatedAt: now.Add(-time.Minute)}, claimed: true},
+		"backing off":      {job: OutboxJob{Status: JobPending, NextAttemptAt: now.Add(time.Minute), UpdatedAt: now.Add(-time.Minute)}},
+		"processing":       {job: OutboxJob{Status: JobProcessing, UpdatedAt: now.Add(-time.Minute)}},
+		"stale processing": {job: OutboxJob{Status: JobProcessing, UpdatedAt: now.Add(-outboxLease - time.Minute)}, claimed: true},
+		"dead":             {job: OutboxJob{Status: JobDead, UpdatedAt: now.Add(-outboxLease - time.Minute)}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			useTestStore(t)
+			tt.job.ID = "job-1"
+			putTestJob(t, tt.job)
+
+			due, err := claimDueJobs(context.Background(), 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claimed := len(due) == 1; claimed != tt.claimed {
+				t.Fatalf("claimed = %v, want %v", claimed, tt.claimed)
+			}
+			if !tt.claimed {
+				return
+			}
+
+			job, err := getJob(context.Background(), "job-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Status != JobProcessing || !job.UpdatedAt.After(tt.job.UpdatedAt) {
+				t.Errorf("stored job is %s since %s, want processing since the claim", job.Status, job.UpdatedAt)
+			}
+			if claims := claimsOf(t, "job-1"); len(claims) != 1 {
+				t.Errorf("got %d claims, want 1", len(claims))
+			}
+		})
+	}
+}
+
+func TestClaimDueJobsLimit(t *testing.T) {
+	useTestStore(t)
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		putTestJob(t, OutboxJob{ID: id, Status: JobPending, UpdatedAt: time.Now()})
+	}
+
+	due, err := claimDueJobs(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("claimed %d jobs, want 2", len(due))
+	}
+
+	pending, err := ListOutbox(context.Background(), JobPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("%d jobs left pending, want 1", len(pending))
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	ctx := context.Background()
+	useTestStore(t)
+	listed := putTestJob(t, OutboxJob{ID: "job-1", Status: JobPending, UpdatedAt: time.Now().Add(-time.Minute)})
+
+	if _, err := claimJob(ctx, listed, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// another replica that listed the same version
+	if _, err := claimJob(ctx, listed, time.Now()); !errors.Is(err, storage.ErrExists) {
+		t.Fatalf("second claim: got %v, want ErrExists", err)
+	}
+
+	// a replica that listed the job before it was delivered and the claims
+	// were removed
+	deleteClaims(ctx, "job-1")
+	if _, err := claimJob(ctx, listed, time.Now()); !errors.Is(err, storage.ErrExists) {
+		t.Fatalf("claim of an old version: got %v, want ErrExists", err)
+	}
+	if claims := claimsOf(t, "job-1"); len(claims) != 0 {
+		t.Errorf("refused claim left %d claim keys", len(claims))
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		9:  time.Hour,
+		64: time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := outboxBackoff(attempts); got != want {
+			t.Errorf("outboxBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestDeliverJobFailure(t *testing.T) {
+	tests := map[string]struct {
+		attempts   int
+		wantStatus string
+	}{
+		"first failure":  {attempts: 0, wantStatus: JobPending},
+		"last retry":     {attempts: outboxMaxAttempts - 2, wantStatus: JobPending},
+		"out of retries": {attempts: outboxMaxAttempts - 1, wantStatus: JobDead},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			useTestStore(t)
+			putTestJob(t, OutboxJob{ID: "job-1", Status: JobPending, Attempts: tt.attempts, UpdatedAt: time.Now()})
+
+			due, err := claimDueJobs(ctx, 1)
+			if err != nil || len(due) != 1 {
+				t.Fatalf("claimDueJobs() = %d jobs, %v", len(due), err)
+			}
+			deliverJob(ctx, due[0])
+
+			job, err := getJob(ctx, "job-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Status != tt.wantStatus || job.Attempts != tt.attempts+1 || job.LastError == "" {
+				t.Errorf("job is %s after %d attempts (%q), want %s after %d", job.Status, job.Attempts, job.LastError, tt.wantStatus, tt.attempts+1)
+			}
+			if tt.wantStatus == JobPending {
+				if wait := job.NextAttemptAt.Sub(job.UpdatedAt); wait != outboxBackoff(job.Attempts) {
+					t.Errorf("next attempt in %s, want %s", wait, outboxBackoff(job.Attempts))
+				}
+			}
+			if claims := claimsOf(t, "job-1"); len(claims) != 0 {
+				t.Errorf("finished delivery left %d claim keys", len(claims))
+			}
+		})
+	}
+}
+
+func TestListJobsBuriesUnreadableJobs(t *testing.T) {
+	ctx := context.Background()
+	s := useTestStore(t)
+	putTestJob(t, OutboxJob{ID: "job-1", Status: JobPending, UpdatedAt: time.Now()})
+	if err := s.Put(ctx, outboxKey("job-2"), []byte("{not json")); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := listJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "job-1" {
+		t.Fatalf("listJobs() = %v, want only job-1", jobs)
+	}
+
+	if _, err := s.Get(ctx, outboxKey("job-2")); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unreadable job is still queued: %v", err)
+	}
+	if data, err := s.Get(ctx, outboxCorruptPrefix+"job-2.json"); err != nil || string(data) != "{not json" {
+		t.Errorf("corrupt copy = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+// CSVHeader holds the columns every sheet must keep, generated sheets add more
+var CSVHeader = []string{"Date", "Author Name", "Commit Type", "Scope", "Description", "TimeStamp"}
+
+var ErrSheetNotFound = errors.New("sheet not found")
+
+var ErrSheetLocked = errors.New("only draft or rejected sheets can be edited")
+
+// eg: 1725815494_sheet-happens_6448_log_final
+var sheetIDRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// serializes read-modify-write cycles on sheets
+var sheetMu sync.Mutex
+
+// SheetValidationError describes why submitted rows were rejected
+type SheetValidationError struct {
+	Row     int // -1 for the header
+	Message string
+}
+
+func (e *SheetValidationError) Error() string {
+	if e.Row < 0 {
+		return "header: " + e.Message
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+func ValidSheetID(id string) bool {
+	return sheetIDRegex.MatchString(id)
+}
+
+func sheetKey(id string) string {
+	return id + ".csv"
+}
+
+// ReadSheetData returns the raw content of the current sheet stored under `id`
+func ReadSheetData(ctx context.Context, id string) ([]byte, error) {
+	if !ValidSheetID(id) {
+		return nil, ErrSheetNotFound
+	}
+
+	data, err := Store.Get(ctx, sheetKey(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrSheetNotFound
+	}
+	return data, err
+}
+
+// ReadSheet returns the header and data rows of the sheet stored under `id`
+func ReadSheet(ctx context.Context, id string) (header []string, rows [][]string, err error) {
+	data, err := ReadSheetData(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parseSheet(data)
+}
+
+func parseSheet(data []byte) (header []string, rows [][]string, err error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse sheet: %w", err)
+	}
+	if len(records) == 0 {
+		return CSVHeader, nil, nil
+	}
+
+	return records[0], records[1:], nil
+}
+
+// ValidateRows checks `rows` against the sheet header. The header must keep
+// the CSVHeader columns and every row must have one value per column.
+func ValidateRows(header []string, rows [][]string) error {
+	for _, col := range CSVHeader {
+		if !slices.Contains(header, col) {
+			return &SheetValidationError{Row: -1, Message: fmt.Sprintf("missing column %q", col)}
+		}
+	}
+
+	dateCol := slices.Index(header, "Date")
+	for i, row := range rows {
+		if len(row) != len(header) {
+			return &SheetValidationError{
+				Row:     i,
+				Message: fmt.Sprintf("expected %d columns, got %d", len(header), len(row)),
+			}
+		}
+		if _, err := time.Parse("2006-01-02 15:04:05", row[dateCol]); err != nil {
+			return &SheetValidationError{Row: i, Message: fmt.Sprintf("invalid date %q", row[dateCol])}
+		}
+	}
+
+	return nil
+}
+
+// writeSheet stores `rows` as a new revision of the sheet under `id` and
+// makes it the current one. Callers must hold sheetMu.
+func writeSheet(ctx context.Context, id string, header []string, rows [][]string) (int, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return 0, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return 0, err
+	}
+
+	return saveRevision(ctx, id, buf.Bytes())
+}
+
+// UpdateSheet loads the sheet, lets `fn` modify its rows, validates the
+// result and stores it as a new revision. Only the uploader, `by`, edits a
+// sheet and only while it is a draft or rejected, a sheet under review or
+// approved is what its reviewers saw. A pending sheet is withdrawn to a draft
+// to edit it.
+func UpdateSheet(ctx context.Context, id, by string, fn func(header []string, rows [][]string) ([][]string, error)) (int, [][]string, error) {
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+
+	meta, err := ReadMeta(ctx, id)
+	switch {
+	case err != nil:
+		return 0, nil, err
+	case !claimOwner(meta, by):
+		return 0, nil, ErrNotOwner
+	case meta.Status != report.SheetStatusDraft && meta.Status != report.SheetStatusRejected:
+		return 0, nil, fmt.Errorf("%w, the sheet is %s", ErrSheetLocked, meta.Status)
+	}
+
+	header, rows, err := ReadSheet(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err = fn(header, rows)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := ValidateRows(header, rows); err != nil {
+		return 0, nil, err
+	}
+
+	version, err := writeSheet(ctx, id, header, rows)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := SaveMeta(ctx, *meta); err != nil {
+		return 0, nil, err
+	}
+
+	return version, rows, nil
+}
@@ -1,12 +1,8 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
-	"net/http"
-	"os"
 )
 
 type blockPayload struct {
@@ -27,56 +23,56 @@ type textObject struct {
 type MessageBody struct {
 	FileName string `json:"file_name"`
 	Url      string `json:"url"`
+	Title    string `json:"title,omitempty"` // defaults to the work log notice
+	Note     string `json:"note,omitempty"`
 }
 
-func sendSlackNotification(webhookURL string, body MessageBody) error {
+func sendSlackNotification(ctx context.Context, webhookURL string, body MessageBody) error {
+	title := body.Title
+	if title == "" {
+		title = "*󱝏 Notice: Work Log Sent*"
+	}
+
+	text := fmt.Sprintf("FileName: *%s*\nURL: *%s*", body.FileName, body.Url)
+	if body.Note != "" {
+		text += "\n" + body.Note
+	}
+
 	payload := blockPayload{
 		Blocks: []block{
 			{
 				Type: "section",
 				Text: &textObject{
 					Type: "mrkdwn",
-					Text: "*󱝏 Notice: Work Log Sent*",
+					Text: title,
 				},
 			},
 			{
 				Type: "section",
 				Text: &textObject{
 					Type: "mrkdwn",
-					Text: fmt.Sprintf(
-						"FileName: *%s*\nURL: *%s*",
-						body.FileName,
-						body.Url,
-					),
+					Text: text,
 				},
 			},
 		},
 	}
 
-	// Convert the message payload to JSON
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("could not marshal message payload: %w", err)
-	}
-
-	// Send POST request to the Slack webhook
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonPayload))
-	if err != nil {
+	if err := postJSON(ctx, webhookURL, payload, nil); err != nil {
 		return fmt.Errorf("failed to send message to Slack: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("non-OK HTTP status: %s", resp.Status)
-	}
 
 	return nil
 }
 
-func SendSlackMessage(message MessageBody) error {
-	webhookURL := os.Getenv("SLACK_WEBHOOK_URL")
-	if webhookURL == "" {
-		return errors.New("Slack Webhook url not found")
-	}
-	return sendSlackNotification(webhookURL, message)
+// SlackNotifier posts messages to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, message MessageBody) error {
+	return sendSlackNotification(ctx, s.WebhookURL, message)
 }
@@ -0,0 +1,7 @@
+package services
+
+import "github.com/webpointsolutions/sheet-happens/internal/storage"
+
+// Store is the backend every sheet read and write goes through, it is set
+// once on startup from config.Env.Storage
+var Store storage.Storage
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// TeamsNotifier posts adaptive cards to a Microsoft Teams incoming webhook or workflow
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func (t *TeamsNotifier) Name() string {
+	return "teams"
+}
+
+func (t *TeamsNotifier) Notify(ctx context.Context, message MessageBody) error {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": messageTitle(message), "weight": "Bolder", "size": "Medium", "wrap": true},
+		{"type": "TextBlock", "text": fmt.Sprintf("FileName: **%s**", message.FileName), "wrap": true},
+	}
+	if message.Note != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": message.Note, "wrap": true})
+	}
+
+	payload := map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+					"actions": []map[string]any{
+						{"type": "Action.OpenUrl", "title": "View Sheet", "url": message.Url},
+					},
+				},
+			},
+		},
+	}
+
+	if err := postJSON(ctx, t.WebhookURL, payload, nil); err != nil {
+		return fmt.Errorf("failed to send message to Teams: %w", err)
+	}
+	return nil
+}
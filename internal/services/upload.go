@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+var ErrNotOwner = errors.New("the sheet was uploaded by someone else")
+
+// SheetUpload is a sheet uploaded by a user or generated by the server
+type SheetUpload struct {
+	// ID, UploadedBy and the fields sent by the CLI, Status and History are
+	// set by UploadSheet
+	Meta  report.SheetMeta
+	Draft bool
+
+	// the sheet, either raw CSV or the rows of a JSON sheet
+	Data   []byte
+	Header []string
+	Rows   [][]string
+}
+
+// UploadSheet validates an uploaded sheet, raw CSV or rows alike, and stores
+// it and its metadata in one step. A sheet uploaded again under its id stays
+// with its uploader and its status moves as in TransitionSheet: a draft is
+// submitted, a rejected sheet resubmitted, a pending sheet is replaced for the
+// same reviewers or withdrawn when uploaded as a draft, and an approved one
+// can't be replaced.
+func UploadSheet(ctx context.Context, upload SheetUpload) (*report.SheetMeta, error) {
+	meta := upload.Meta
+	if err := ValidateMeta(meta); err != nil {
+		return nil, err
+	}
+
+	header, rows := upload.Header, upload.Rows
+	if upload.Data != nil {
+		var err error
+		if header, rows, err = parseUpload(upload.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := ValidateRows(header, rows); err != nil {
+		return nil, err
+	}
+
+	status := report.SheetStatusSubmitted
+	if upload.Draft {
+		status = report.SheetStatusDraft
+	} else if len(meta.Receivers) == 0 {
+		return nil, ErrNoReceivers
+	}
+
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+
+	existing, err := ReadMeta(ctx, meta.ID)
+	switch {
+	case errors.Is(err, ErrSheetNotFound):
+		meta.History, meta.Reason = nil, ""
+		RecordStatus(&meta, status, meta.UploadedBy, "")
+
+	case err != nil:
+		return nil, err
+
+	default:
+		if !claimOwner(existing, meta.UploadedBy) {
+			return nil, ErrNotOwner
+		}
+		meta.Status, meta.Reason, meta.History = existing.Status, existing.Reason, existing.History
+
+		pending := meta.Status == report.SheetStatusSubmitted || meta.Status == report.SheetStatusResubmitted
+		switch {
+		case meta.Status == status, pending && !upload.Draft:
+			// replaced in place
+		case meta.Status == report.SheetStatusRejected && upload.Draft:
+			// fixed, but not resubmitted yet
+		case meta.Status == report.SheetStatusRejected:
+			err = transitionTo(&meta, report.SheetStatusResubmitted, meta.UploadedBy, "")
+		default:
+			err = transitionTo(&meta, status, meta.UploadedBy, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if upload.Data != nil {
+		_, err = saveRevision(ctx, meta.ID, upload.Data)
+	} else {
+		_, err = writeSheet(ctx, meta.ID, upload.Header, upload.Rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SaveMeta(ctx, meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// parseUpload reads a raw CSV upload, a malformed line is reported like an
+// invalid row
+func parseUpload(data []byte) (header []string, rows [][]string, err error) {
+	header, rows, err = parseSheet(data)
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// line 1 is the header
+		return nil, nil, &SheetValidationError{Row: max(parseErr.Line-2, -1), Message: parseErr.Err.Error()}
+	}
+	return header, rows, err
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
+func TestUploadSheetValidation(t *testing.T) {
+	const header = "Date,Author Name,Commit Type,Scope,Description,TimeStamp\n"
+
+	tests := map[string]struct {
+		data    string
+		wantRow int // -1 = the header, -2 = valid
+	}{
+		"valid":           {data: header + "2025-04-01 09:00:00,Jane,feat,,login form,0.5\n", wantRow: -2},
+		"extra columns":   {data: "Date,Author Name,Commit Type,Scope,Description,TimeStamp,Hours\n2025-04-01 09:00:00,Jane,feat,,login form,0.5,0.5\n", wantRow: -2},
+		"header only":     {data: header, wantRow: -2},
+		"missing column":  {data: "Date,Author Name,Description\n2025-04-01 09:00:00,Jane,login form\n", wantRow: -1},
+		"short row":       {data: header + "2025-04-01 09:00:00,Jane,feat,,login form,0.5\n2025-04-01 10:00:00,Jane\n", wantRow: 1},
+		"invalid date":    {data: header + "yesterday,Jane,feat,,login form,0.5\n", wantRow: 0},
+		"broken quote":    {data: header + "2025-04-01 09:00:00,Jane,feat,,\"login form,0.5\n", wantRow: 0},
+		"quote in header": {data: "Date,\"Author Name\n", wantRow: -1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			useTestStore(t)
+
+			_, err := UploadSheet(ctx, SheetUpload{
+				Meta: report.SheetMeta{ID: testSheetID, UploadedBy: testUploader, Receivers: []string{testReviewer}},
+				Data: []byte(tt.data),
+			})
+
+			if tt.wantRow == -2 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := ReadSheetData(ctx, testSheetID)
+				if err != nil || string(data) != tt.data {
+					t.Errorf("stored %q, %v, want the upload as is", data, err)
+				}
+				return
+			}
+
+			var validationErr *SheetValidationError
+			if !errors.As(err, &validationErr) || validationErr.Row != tt.wantRow {
+				t.Fatalf("got %v, want a validation error for row %d", err, tt.wantRow)
+			}
+			if _, err := ReadMeta(ctx, testSheetID); !errors.Is(err, ErrSheetNotFound) {
+				t.Errorf("refused upload was stored: %v", err)
+			}
+		})
+	}
+}
+
+func TestUploadSheetMetaControlCharacters(t *testing.T) {
+	tests := map[string]report.SheetMeta{
+		"repo":        {Repo: "sheet-happens\r\nBcc: everyone@example.com"},
+		"description": {Description: "april\nhours"},
+		"branch":      {Branch: "main\x00"},
+	}
+
+	for name, meta := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			useTestStore(t)
+
+			meta.ID, meta.UploadedBy = testSheetID, testUploader
+			_, err := UploadSheet(ctx, SheetUpload{Meta: meta, Header: CSVHeader, Rows: [][]string{rowA}})
+			if !errors.Is(err, ErrInvalidMeta) {
+				t.Fatalf("got %v, want ErrInvalidMeta", err)
+			}
+			if _, err := ReadMeta(ctx, testSheetID); !errors.Is(err, ErrSheetNotFound) {
+				t.Errorf("refused upload was stored: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
+)
+
+var ErrVersionNotFound = errors.New("version not found")
+var ErrDiffTooLarge = errors.New("too many changed rows to compare the versions")
+
+// maxDiffCells bounds the LCS table DiffVersions builds for the changed rows,
+// 16 MB of int32
+const maxDiffCells = 4 << 20
+
+type SheetVersion struct {
+	Version   int       `json:"version"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RowChange struct {
+	Index int      `json:"index"`
+	Row   []string `json:"row"`
+}
+
+type RowEdit struct {
+	FromIndex int      `json:"from_index"`
+	ToIndex   int      `json:"to_index"`
+	Before    []string `json:"before"`
+	After     []string `json:"after"`
+	Columns   []string `json:"columns"` // names of the changed columns
+}
+
+type SheetDiff struct {
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Header  []string    `json:"header"`
+	Added   []RowChange `json:"added"`
+	Removed []RowChange `json:"removed"`
+	Edited  []RowEdit   `json:"edited"`
+
+	// columns of Header that `from` didn't have and columns it had that are gone
+	AddedColumns   []string `json:"added_columns"`
+	RemovedColumns []string `json:"removed_columns"`
+}
+
+// every revision of a sheet is kept as versions/{id}/{n}.csv
+func versionsPrefix(id string) string {
+	return "versions/" + id + "/"
+}
+
+func versionKey(id string, version int) string {
+	return fmt.Sprintf("%s%d.csv", versionsPrefix(id), version)
+}
+
+// ListVersions returns the revisions of a sheet, oldest first
+func ListVersions(ctx context.Context, id string) ([]SheetVersion, error) {
+	if !ValidSheetID(id) {
+		return nil, ErrSheetNotFound
+	}
+
+	objects, err := Store.List(ctx, versionsPrefix(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []SheetVersion
+	for _, obj := range objects {
+		n, err := strconv.Atoi(strings.TrimSuffix(path.Base(obj.Key), ".csv"))
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, SheetVersion{Version: n, Size: obj.Size, CreatedAt: obj.ModTime})
+	}
+
+	// sheets saved before revisions were kept only have the current file
+	if len(versions) == 0 {
+		obj, err := Store.Stat(ctx, sheetKey(id))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, ErrSheetNotFound
+			}
+			return nil, err
+		}
+		return []SheetVersion{{Version: 1, Size: obj.Size, CreatedAt: obj.ModTime}}, nil
+	}
+
+	slices.SortFunc(versions, func(a, b SheetVersion) int { return a.Version - b.Version })
+	return versions, nil
+}
+
+// ReadVersionData returns the raw content of revision `version`
+func ReadVersionData(ctx context.Context, id string, version int) ([]byte, error) {
+	versions, err := ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(versions, func(v SheetVersion) bool { return v.Version == version }) {
+		return nil, ErrVersionNotFound
+	}
+
+	data, err := Store.Get(ctx, versionKey(id, version))
+	if errors.Is(err, storage.ErrNotFound) {
+		return ReadSheetData(ctx, id) // legacy sheet without revisions
+	}
+	return data, err
+}
+
+// ReadVersion returns the header and data rows of revision `version`
+func ReadVersion(ctx context.Context, id string, version int) (header []string, rows [][]string, err error) {
+	data, err := ReadVersionData(ctx, id, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parseSheet(data)
+}
+
+// DiffVersions compares two revisions row by row. Unchanged rows are matched
+// first; rows removed and added between the same unchanged neighbours are
+// reported as edits. When the header changed the rows of `from` are read by
+// column name in the header of `to`, a new column counts as empty before.
+// ErrDiffTooLarge is returned when too many rows changed to compare them.
+func DiffVersions(ctx context.Context, id string, from, to int) (*SheetDiff, error) {
+	fromHeader, before, err := ReadVersion(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	header, after, err := ReadVersion(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SheetDiff{
+		From:    from,
+		To:      to,
+		Header:  header,
+		Added:   []RowChange{},
+		Removed: []RowChange{},
+		Edited:  []RowEdit{},
+
+		AddedColumns:   []string{},
+		RemovedColumns: []string{},
+	}
+
+	if !slices.Equal(fromHeader, header) {
+		for _, col := range header {
+			if !slices.Contains(fromHeader, col) {
+				diff.AddedColumns = append(diff.AddedColumns, col)
+			}
+		}
+		for _, col := range fromHeader {
+			if !slices.Contains(header, col) {
+				diff.RemovedColumns = append(diff.RemovedColumns, col)
+			}
+		}
+		before = realignRows(fromHeader, header, before)
+	}
+
+	// rows are compared by id, identical rows share one
+	ids := map[string]int{}
+	rowIDs := func(rows [][]string) []int {
+		out := make([]int, len(rows))
+		for k, row := range rows {
+			key := rowKey(row)
+			id, ok := ids[key]
+			if !ok {
+				id = len(ids)
+				ids[key] = id
+			}
+			out[k] = id
+		}
+		return out
+	}
+	a, b := rowIDs(before), rowIDs(after)
+
+	// only the rows between the unchanged head and tail need the table
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+	}
+	if (endA-start)*(endB-start) > maxDiffCells {
+		return nil, ErrDiffTooLarge
+	}
+
+	// longest common subsequence of identical rows, lcs(i, j) is the length
+	// for before[i:endA] and after[j:endB]
+	width := endB - start + 1
+	table := make([]int32, (endA-start+1)*width)
+	lcs := func(i, j int) int32 { return table[(i-start)*width+j-start] }
+	for i := endA - 1; i >= start; i-- {
+		for j := endB - 1; j >= start; j-- {
+			if a[i] == b[j] {
+				table[(i-start)*width+j-start] = lcs(i+1, j+1) + 1
+			} else {
+				table[(i-start)*width+j-start] = max(lcs(i+1, j), lcs(i, j+1))
+			}
+		}
+	}
+
+	var removed, added []int
+	flush := func() {
+		n := min(len(removed), len(added))
+		for k := 0; k < n; k++ {
+			i, j := removed[k], added[k]
+			diff.Edited = append(diff.Edited, RowEdit{
+				FromIndex: i,
+				ToIndex:   j,
+				Before:    before[i],
+				After:     after[j],
+				Columns:   changedColumns(header, before[i], after[j]),
+			})
+		}
+		for _, i := range removed[n:] {
+			diff.Removed = append(diff.Removed, RowChange{Index: i, Row: before[i]})
+		}
+		for _, j := range added[n:] {
+			diff.Added = append(diff.Added, RowChange{Index: j, Row: after[j]})
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := start, start
+	for i < endA || j < endB {
+		switch {
+		case i < endA && j < endB && a[i] == b[j]:
+			flush()
+			i++
+			j++
+		case j == endB || (i < endA && lcs(i+1, j) >= lcs(i, j+1)):
+			removed = append(removed, i)
+			i++
+		default:
+			added = append(added, j)
+			j++
+		}
+	}
+	flush()
+
+	return diff, nil
+}
+
+// rowKey encodes `row` so that two rows have the same key only when every
+// cell is the same
+func rowKey(row []string) string {
+	var key strings.Builder
+	for _, cell := range row {
+		key.WriteString(strconv.Itoa(len(cell)))
+		key.WriteByte(':')
+		key.WriteString(cell)
+	}
+	return key.String()
+}
+
+// realignRows reorders `rows` read with header `from` into the columns of
+// header `to`, columns missing from `from` are left empty
+func realignRows(from, to []string, rows [][]string) [][]string {
+	realigned := make([][]string, len(rows))
+	for i, row := range rows {
+		realigned[i] = make([]string, len(to))
+		for k, col := range to {
+			if index := slices.Index(from, col); index >= 0 && index < len(row) {
+				realigned[i][k] = row[index]
+			}
+		}
+	}
+	return realigned
+}
+
+func changedColumns(header, before, after []string) []string {
+	var columns []string
+	for k := 0; k < max(len(before), len(after)); k++ {
+		var a, b string
+		if k < len(before) {
+			a = before[k]
+		}
+		if k < len(after) {
+			b = after[k]
+		}
+		if a == b {
+			continue
+		}
+
+		name := strconv.Itoa(k)
+		if k < len(header) {
+			name = header[k]
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// saveRevision writes `data` as the next revision of a sheet and then as the
+// current sheet. Revision numbers are claimed with Store.Create, so replicas
+// sharing a backend never overwrite each other's revision; sheetMu only
+// spares this process the retries.
+func saveRevision(ctx context.Context, id string, data []byte) (int, error) {
+	if !ValidSheetID(id) {
+		return 0, ErrSheetNotFound
+	}
+
+	versions, err := ListVersions(ctx, id)
+	if err != nil && !errors.Is(err, ErrSheetNotFound) {
+		return 0, err
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		last := versions[len(versions)-1].Version
+
+		// keep the original of a sheet saved before revisions existed
+		if _, err := Store.Stat(ctx, versionKey(id, last)); errors.Is(err, storage.ErrNotFound) {
+			current, err := ReadSheetData(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			if err := Store.Create(ctx, versionKey(id, last), current); err != nil && !errors.Is(err, storage.ErrExists) {
+				return 0, err
+			}
+		}
+		next = last + 1
+	}
+
+	// another replica took `next`, try the one after it
+	for {
+		err := Store.Create(ctx, versionKey(id, next), data)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, storage.ErrExists) {
+			return 0, err
+		}
+		next++
+	}
+
+	if err := Store.Put(ctx, sheetKey(id), data); err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testSheetID = "1725815494_sheet-happens_6448_log"
+
+var (
+	rowA = []string{"2025-04-01 09:00:00", "Jane", "feat", "", "login form", "0.5"}
+	rowB = []string{"2025-04-01 10:00:00", "Jane", "fix", "", "login typo", "1"}
+	rowC = []string{"2025-04-01 11:00:00", "Jane", "feat", "", "logout", "1"}
+	rowD = []string{"2025-04-01 12:00:00", "Jane", "feat", "", "signup form", "1"}
+)
+
+// withHours returns `row` with its TimeStamp replaced
+func withHours(row []string, hours string) []string {
+	edited := append([]string(nil), row...)
+	edited[5] = hours
+	return edited
+}
+
+// saveTestRevisions stores each of `revisions` as the next revision of the
+// test sheet, the first row of a revision is its header
+func saveTestRevisions(t *testing.T, revisions ...[][]string) {
+	t.Helper()
+
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+
+	for _, revision := range revisions {
+		if _, err := writeSheet(context.Background(), testSheetID, revision[0], revision[1:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	tests := map[string]struct {
+		before, after [][]string
+		want          SheetDiff
+	}{
+		"unchanged": {
+			before: [][]string{rowA, rowB},
+			after:  [][]string{rowA, rowB},
+			want:   SheetDiff{},
+		},
+		"insert": {
+			before: [][]string{rowA, rowC},
+			after:  [][]string{rowA, rowB, rowC, rowD},
+			want: SheetDiff{
+				Added: []RowChange{{Index: 1, Row: rowB}, {Index: 3, Row: rowD}},
+			},
+		},
+		"delete": {
+			before: [][]string{rowA, rowB, rowC, rowD},
+			after:  [][]string{rowB, rowD},
+			want: SheetDiff{
+				Removed: []RowChange{{Index: 0, Row: rowA}, {Index: 2, Row: rowC}},
+			},
+		},
+		"edit": {
+			before: [][]string{rowA, rowB, rowC},
+			after:  [][]string{rowA, withHours(rowB, "2"), rowC},
+			want: SheetDiff{
+				Edited: []RowEdit{{FromIndex: 1, ToIndex: 1, Before: rowB, After: withHours(rowB, "2"), Columns: []string{"TimeStamp"}}},
+			},
+		},
+		"edit next to an insert": {
+			before: [][]string{rowA, rowB, rowD},
+			after:  [][]string{rowA, withHours(rowB, "2"), rowC, rowD},
+			want: SheetDiff{
+				Added:  []RowChange{{Index: 2, Row: rowC}},
+				Edited: []RowEdit{{FromIndex: 1, ToIndex: 1, Before: rowB, After: withHours(rowB, "2"), Columns: []string{"TimeStamp"}}},
+			},
+		},
+		"edit and delete at the end": {
+			before: [][]string{rowA, rowB, rowC},
+			after:  [][]string{rowA, withHours(rowB, "2")},
+			want: SheetDiff{
+				Removed: []RowChange{{Index: 2, Row: rowC}},
+				Edited:  []RowEdit{{FromIndex: 1, ToIndex: 1, Before: rowB, After: withHours(rowB, "2"), Columns: []string{"TimeStamp"}}},
+			},
+		},
+		"reorder": {
+			before: [][]string{rowA, rowB, rowC},
+			after:  [][]string{rowB, rowC, rowA},
+			want: SheetDiff{
+				Added:   []RowChange{{Index: 2, Row: rowA}},
+				Removed: []RowChange{{Index: 0, Row: rowA}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			useTestStore(t)
+			saveTestRevisions(t, append([][]string{CSVHeader}, tt.before...), append([][]string{CSVHeader}, tt.after...))
+
+			got, err := DiffVersions(context.Background(), testSheetID, 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := tt.want
+			want.From, want.To, want.Header = 1, 2, CSVHeader
+			for _, changes := range []*[]RowChange{&want.Added, &want.Removed} {
+				if *changes == nil {
+					*changes = []RowChange{}
+				}
+			}
+			if want.Edited == nil {
+				want.Edited = []RowEdit{}
+			}
+			want.AddedColumns, want.RemovedColumns = []string{}, []string{}
+
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("DiffVersions() =\n%+v\nwant\n%+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDiffVersionsHeaderChange(t *testing.T) {
+	useTestStore(t)
+
+	// Scope is dropped, a Ticket column added and Description moved to the end
+	header := []string{"Date", "Author Name", "Commit Type", "TimeStamp", "Ticket", "Description"}
+	realign := func(row []string, ticket string) []string {
+		return []string{row[0], row[1], row[2], row[5], ticket, row[4]}
+	}
+	saveTestRevisions(t,
+		[][]string{CSVHeader, rowA, rowB, rowC},
+		[][]string{header, realign(rowA, ""), realign(rowB, "PROJ-7"), realign(withHours(rowC, "3"), "")},
+	)
+
+	got, err := DiffVersions(context.Background(), testSheetID, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.AddedColumns, []string{"Ticket"}) || !reflect.DeepEqual(got.RemovedColumns, []string{"Scope"}) {
+		t.Errorf("columns added %q and removed %q, want Ticket and Scope", got.AddedColumns, got.RemovedColumns)
+	}
+	if len(got.Added) != 0 || len(got.Removed) != 0 {
+		t.Errorf("added %v and removed %v, want only edits", got.Added, got.Removed)
+	}
+
+	var edits []string
+	for _, edit := range got.Edited {
+		edits = append(edits, edit.After[5]+": "+strings.Join(edit.Columns, ", "))
+	}
+	want := []string{"login typo: Ticket", "logout: TimeStamp"}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("edits = %q, want %q", edits, want)
+	}
+}
+
+// testRows returns `n` distinct rows described `prefix` 0 to n-1
+func testRows(prefix string, n int) [][]string {
+	rows := make([][]string, n)
+	for k := range rows {
+		rows[k] = []string{"2025-04-01 09:00:00", "Jane", "feat", "", prefix + strconv.Itoa(k), "0.5"}
+	}
+	return rows
+}
+
+func TestDiffVersionsLargeSheet(t *testing.T) {
+	useTestStore(t)
+
+	// a long sheet with one row edited in the middle only compares that row
+	before := testRows("row ", 50000)
+	after := slices.Clone(before)
+	after[25000] = withHours(before[25000], "2")
+	saveTestRevisions(t, append([][]string{CSVHeader}, before...), append([][]string{CSVHeader}, after...))
+
+	got, err := DiffVersions(context.Background(), testSheetID, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Added) != 0 || len(got.Removed) != 0 || len(got.Edited) != 1 || got.Edited[0].FromIndex != 25000 {
+		t.Errorf("got %d added, %d removed and edits %+v, want row 25000 edited", len(got.Added), len(got.Removed), got.Edited)
+	}
+}
+
+func TestDiffVersionsTooLarge(t *testing.T) {
+	useTestStore(t)
+	saveTestRevisions(t,
+		append([][]string{CSVHeader}, testRows("before ", 2100)...),
+		append([][]string{CSVHeader}, testRows("after ", 2100)...),
+	)
+
+	if _, err := DiffVersions(context.Background(), testSheetID, 1, 2); !errors.Is(err, ErrDiffTooLarge) {
+		t.Fatalf("got %v, want ErrDiffTooLarge", err)
+	}
+}
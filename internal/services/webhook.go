@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, eg: sha256=5d41402a...
+const SignatureHeader = "X-Sheet-Happens-Signature"
+
+// WebhookNotifier posts plain JSON to any endpoint, signed with `Secret` when set
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+}
+
+type webhookPayload struct {
+	Title    string    `json:"title"`
+	FileName string    `json:"file_name"`
+	Url      string    `json:"url"`
+	Note     string    `json:"note,omitempty"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, message MessageBody) error {
+	body, err := json.Marshal(webhookPayload{
+		Title:    messageTitle(message),
+		FileName: message.FileName,
+		Url:      message.Url,
+		Note:     message.Note,
+		SentAt:   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal message payload: %w", err)
+	}
+
+	headers := map[string]string{}
+	if w.Secret != "" {
+		headers[SignatureHeader] = "sha256=" + SignWebhookBody(w.Secret, body)
+	}
+
+	if err := postBody(ctx, w.URL, body, headers); err != nil {
+		return fmt.Errorf("failed to send message to webhook: %w", err)
+	}
+	return nil
+}
+
+// SignWebhookBody returns the hex HMAC-SHA256 receivers compare against SignatureHeader
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
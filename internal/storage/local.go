@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Local stores objects as files below a root directory
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		root = "out"
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{root: root}, nil
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+func (l *Local) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put writes to a temporary file first and renames it into place
+func (l *Local) Put(_ context.Context, key string, data []byte) error {
+	dst := l.path(key)
+	tmp, err := l.writeTemp(dst, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Rename(tmp, dst)
+}
+
+// Create hard links a temporary file into place, which fails when the key exists
+func (l *Local) Create(_ context.Context, key string, data []byte) error {
+	dst := l.path(key)
+	tmp, err := l.writeTemp(dst, data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if err := os.Link(tmp, dst); err != nil {
+		if os.IsExist(err) {
+			return ErrExists
+		}
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes `data` to a hidden file next to `dst` and returns its path
+func (l *Local) writeTemp(dst string, data []byte) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Stat(_ context.Context, key string) (Object, error) {
+	info, err := os.Stat(l.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Object{}, ErrNotFound
+		}
+		return Object{}, err
+	}
+	if info.IsDir() {
+		return Object{}, ErrNotFound
+	}
+
+	return Object{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List only walks the directory `prefix` is in, eg: versions/{id} for versions/{id}/
+func (l *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+
+	dir := l.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = l.path(prefix[:i])
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		// skips temporary files of in-flight writes
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(objects, func(a, b Object) int { return strings.Compare(a.Key, b.Key) })
+	return objects, nil
+}
+
+func (l *Local) ListDir(_ context.Context, dir string) ([]Object, error) {
+	entries, err := os.ReadDir(l.path(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var objects []Object
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, Object{Key: dir + entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return objects, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 stores objects in a bucket of any S3 compatible service. Requests use
+// path style addressing so a local MinIO instance works as a stand-in.
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(cfg Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return data, nil
+}
+
+// Put relies on S3 replacing objects atomically
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return err
+}
+
+// Create is a conditional put with `If-None-Match: *`
+func (s *S3) Create(ctx context.Context, key string, data []byte) error {
+	opts := minio.PutObjectOptions{ContentType: "application/octet-stream"}
+	opts.SetMatchETagExcept("*")
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), opts)
+	return s3Error(err)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return Object{}, s3Error(err)
+	}
+
+	return Object{Key: key, Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	return s.list(ctx, prefix, true)
+}
+
+func (s *S3) ListDir(ctx context.Context, dir string) ([]Object, error) {
+	return s.list(ctx, dir, false)
+}
+
+func (s *S3) list(ctx context.Context, prefix string, recursive bool) ([]Object, error) {
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if info.Err != nil {
+			return nil, s3Error(info.Err)
+		}
+		if strings.HasSuffix(info.Key, "/") {
+			continue // a subdirectory when not recursive
+		}
+		objects = append(objects, Object{Key: info.Key, Size: info.Size, ModTime: info.LastModified})
+	}
+
+	return objects, nil
+}
+
+// s3Error maps missing keys to ErrNotFound and failed conditional puts to
+// ErrExists, a 409 is another replica's conditional put in flight
+func s3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey":
+		return ErrNotFound
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return ErrExists
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite stores objects as blobs in a single table
+type SQLite struct {
+	db *sql.DB
+}
+
+func NewSQLite(dsn string) (*SQLite, error) {
+	if dsn == "" {
+		dsn = "sheet-happens.db"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// writes are serialized by sqlite anyway, a single connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS objects (
+		key        TEXT PRIMARY KEY,
+		data       BLOB NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Get(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM objects WHERE key = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *SQLite) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO objects (key, data, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		key, data, time.Now().UnixNano(),
+	)
+	return err
+}
+
+func (s *SQLite) Create(ctx context.Context, key string, data []byte) error {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO objects (key, data, updated_at) VALUES (?, ?, ?) ON CONFLICT (key) DO NOTHING`,
+		key, data, time.Now().UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+func (s *SQLite) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM objects WHERE key = ?`, key)
+	return err
+}
+
+func (s *SQLite) Stat(ctx context.Context, key string) (Object, error) {
+	var size, updatedAt int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT length(data), updated_at FROM objects WHERE key = ?`, key,
+	).Scan(&size, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Object{}, ErrNotFound
+	}
+	if err != nil {
+		return Object{}, err
+	}
+
+	return Object{Key: key, Size: size, ModTime: time.Unix(0, updatedAt)}, nil
+}
+
+func (s *SQLite) List(ctx context.Context, prefix string) ([]Object, error) {
+	return s.list(ctx,
+		`SELECT key, length(data), updated_at FROM objects WHERE substr(key, 1, length(?1)) = ?1 ORDER BY key`,
+		prefix,
+	)
+}
+
+func (s *SQLite) ListDir(ctx context.Context, dir string) ([]Object, error) {
+	return s.list(ctx,
+		`SELECT key, length(data), updated_at FROM objects
+		WHERE substr(key, 1, length(?1)) = ?1 AND instr(substr(key, length(?1) + 1), '/') = 0 ORDER BY key`,
+		dir,
+	)
+}
+
+func (s *SQLite) list(ctx context.Context, query string, prefix string) ([]Object, error) {
+	rows, err := s.db.QueryContext(ctx, query, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var obj Object
+		var updatedAt int64
+		if err := rows.Scan(&obj.Key, &obj.Size, &updatedAt); err != nil {
+			return nil, err
+		}
+		obj.ModTime = time.Unix(0, updatedAt)
+		objects = append(objects, obj)
+	}
+
+	return objects, rows.Err()
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("object not found")
+	ErrExists   = errors.New("object already exists")
+)
+
+// Storage keeps sheets and everything derived from them under slash
+// separated keys, eg: `versions/{id}/{n}.csv`
+type Storage interface {
+	// Get returns the content stored under `key` or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put replaces the content stored under `key`. Readers either see the
+	// previous or the new content, never a partial write.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Create stores `data` under `key` unless the key is taken, then it
+	// returns ErrExists. Replicas sharing a backend use it to claim keys.
+	Create(ctx context.Context, key string, data []byte) error
+
+	// Delete removes `key`, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+
+	// Stat returns the object stored under `key` or ErrNotFound
+	Stat(ctx context.Context, key string) (Object, error)
+
+	// List returns every object whose key starts with `prefix`, sorted by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// ListDir is List without the objects in subdirectories of `dir`, which
+	// is "" or ends with a slash, eg: "" returns the current sheets but not
+	// their revisions
+	ListDir(ctx context.Context, dir string) ([]Object, error)
+}
+
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+type Config struct {
+	Driver string // local, sqlite or s3
+
+	// local
+	Path string
+
+	// sqlite
+	DSN string
+
+	// s3
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// New opens the storage backend selected by `cfg.Driver`
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.Path)
+	case "sqlite":
+		return NewSQLite(cfg.DSN)
+	case "s3":
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		"local": func(t *testing.T) Storage {
+			s, err := NewLocal(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"sqlite": func(t *testing.T) Storage {
+			s, err := NewSQLite(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+		"s3": func(t *testing.T) Storage {
+			srv := httptest.NewServer(newFakeS3("sheets"))
+			t.Cleanup(srv.Close)
+
+			s, err := NewS3(Config{
+				Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+				Bucket:    "sheets",
+				Region:    "us-east-1",
+				AccessKey: "key",
+				SecretKey: "secret",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			testStorage(t, open(t))
+		})
+	}
+}
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "missing.csv"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing: got %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat(ctx, "missing.csv"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat missing: got %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, "a.csv", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "a.csv", []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.Get(ctx, "a.csv"); err != nil || string(data) != "two" {
+		t.Fatalf("Get after Put: got %q, %v", data, err)
+	}
+	if obj, err := s.Stat(ctx, "a.csv"); err != nil || obj.Key != "a.csv" || obj.Size != 3 {
+		t.Fatalf("Stat: got %+v, %v", obj, err)
+	}
+
+	if err := s.Create(ctx, "versions/ab/1.csv", []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(ctx, "versions/ab/1.csv", []byte("second")); !errors.Is(err, ErrExists) {
+		t.Fatalf("Create taken key: got %v, want ErrExists", err)
+	}
+	if data, _ := s.Get(ctx, "versions/ab/1.csv"); string(data) != "first" {
+		t.Fatalf("Create overwrote the existing object: %q", data)
+	}
+
+	for _, key := range []string{"versions/ab/2.csv", "versions/abc/1.csv", "meta/ab.json"} {
+		if err := s.Put(ctx, key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lists := map[string][]string{
+		"versions/ab/": {"versions/ab/1.csv", "versions/ab/2.csv"},
+		"versions/ab":  {"versions/ab/1.csv", "versions/ab/2.csv", "versions/abc/1.csv"},
+		"nothing/":     nil,
+		"":             {"a.csv", "meta/ab.json", "versions/ab/1.csv", "versions/ab/2.csv", "versions/abc/1.csv"},
+	}
+	for prefix, want := range lists {
+		objects, err := s.List(ctx, prefix)
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		var keys []string
+		for _, obj := range objects {
+			keys = append(keys, obj.Key)
+		}
+		if !slices.Equal(keys, want) {
+			t.Errorf("List(%q) = %v, want %v", prefix, keys, want)
+		}
+	}
+
+	dirs := map[string][]string{
+		"":             {"a.csv"},
+		"versions/":    nil,
+		"versions/ab/": {"versions/ab/1.csv", "versions/ab/2.csv"},
+		"nothing/":     nil,
+	}
+	for dir, want := range dirs {
+		objects, err := s.ListDir(ctx, dir)
+		if err != nil {
+			t.Fatalf("ListDir(%q): %v", dir, err)
+		}
+		var keys []string
+		for _, obj := range objects {
+			keys = append(keys, obj.Key)
+		}
+		if !slices.Equal(keys, want) {
+			t.Errorf("ListDir(%q) = %v, want %v", dir, keys, want)
+		}
+	}
+
+	if err := s.Delete(ctx, "a.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a.csv"); err != nil {
+		t.Fatalf("Delete missing: %v", err)
+	}
+	if _, err := s.Get(ctx, "a.csv"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+// fakeS3 is an in-memory stand-in for the parts of the S3 API the S3 store
+// uses, with path style addressing and conditional puts
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string]fakeObject)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		s3ErrorResponse(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+
+	case r.Method == http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			s3ErrorResponse(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		if _, ok := f.objects[key]; ok && r.Header.Get("If-None-Match") == "*" {
+			s3ErrorResponse(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		f.objects[key] = fakeObject{data: data, modTime: time.Now().UTC()}
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(data))+`"`)
+
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			s3ErrorResponse(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(obj.data))+`"`)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s3ErrorResponse(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	type content struct {
+		Key          string
+		Size         int64
+		LastModified string
+		ETag         string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName        xml.Name `xml:"ListBucketResult"`
+		Name           string
+		Prefix         string
+		KeyCount       int
+		IsTruncated    bool
+		Contents       []content
+		CommonPrefixes []commonPrefix
+	}{Name: f.bucket, Prefix: prefix}
+
+	dirs := make(map[string]bool)
+	for key, obj := range f.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			dir := key[:len(prefix)+i+len(delimiter)]
+			if !dirs[dir] {
+				dirs[dir] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: dir})
+			}
+			continue
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			Size:         int64(len(obj.data)),
+			LastModified: obj.modTime.Format(time.RFC3339),
+			ETag:         `"` + strconv.Itoa(len(obj.data)) + `"`,
+		})
+	}
+	slices.SortFunc(result.Contents, func(a, b content) int { return strings.Compare(a.Key, b.Key) })
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// readBody reads a put, decoding the aws-chunked encoding clients use for
+// signed uploads over plain http
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, err
+		}
+		if _, err := body.Discard(2); err != nil { // \r\n after the chunk
+			return nil, err
+		}
+	}
+}
+
+func s3ErrorResponse(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
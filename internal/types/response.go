@@ -1,5 +1,11 @@
 package types
 
+import (
+	"time"
+
+	"github.com/webpointsolutions/sheet-happens/pkg/report"
+)
+
 type ApiResponese struct {
 	Success bool `json:"success"`
 	Payload any  `json:"payload"`
@@ -9,6 +15,86 @@ type LoginRequest struct {
 	Email string `json:"email"`
 }
 
-type LoginResponse struct {
+type TokenCreateRequest struct {
 	Name string `json:"name"`
 }
+
+type LoginVerifyRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+type LoginResponse struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SheetUpdateRequest struct {
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+// RemoteSheetRequest generates a sheet on the server from a repository URL,
+// dates are YYYY-MM-DD in the server's timezone
+type RemoteSheetRequest struct {
+	URL            string   `json:"url"`
+	Branch         string   `json:"branch"`
+	Depth          int      `json:"depth"`      // commits fetched per branch, 0 = full history
+	SinceDays      int      `json:"since_days"` // -t window, 0 = all history
+	Since          string   `json:"since"`
+	Until          string   `json:"until"`
+	Period         string   `json:"period"`     // eg: last-month
+	WeekStart      string   `json:"week_start"` // eg: sunday, defaults to WEEK_START
+	Timezone       string   `json:"timezone"`   // eg: Asia/Kathmandu, defaults to TIMEZONE
+	Authors        []string `json:"authors"`    // empty = every author
+	ExcludeAuthors []string `json:"exclude_authors"`
+	Paths          []string `json:"paths"` // empty = whole tree
+	Tags           bool     `json:"tags"`
+	Refs           []string `json:"refs"` // ref globs, eg: refs/pull/*
+	ExcludeRefs    []string `json:"exclude_refs"`
+
+	Hash       bool `json:"hash"`
+	ShortHash  bool `json:"short_hash"`
+	ShowBranch bool `json:"show_branch"`
+	DiffStats  bool `json:"diff_stats"`
+
+	Merges          string   `json:"merges"`  // keep, drop or collapse
+	Reverts         string   `json:"reverts"` // keep, pair or drop
+	ExcludeMessages []string `json:"exclude_messages"`
+	ExcludeBots     bool     `json:"exclude_bots"`
+	ShowDropped     bool     `json:"show_dropped"`
+
+	Description string `json:"description"`
+	Draft       bool   `json:"draft"`
+}
+
+type RowUpdateRequest struct {
+	Row []string `json:"row"`
+}
+
+type ReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+type SheetListQuery struct {
+	Repo     string
+	Uploader string
+	Status   string
+	Text     string // searched in the commit descriptions of the sheet
+	From     time.Time
+	To       time.Time
+
+	Sort  string // uploaded_at, repo, uploader or id
+	Desc  bool
+	Page  int
+	Limit int
+}
+
+type SheetList struct {
+	Items []report.SheetMeta `json:"items"`
+	Total *int               `json:"total,omitempty"` // left out with a text search, which stops reading sheets once More is known
+	Page  int                `json:"page"`
+	Limit int                `json:"limit"`
+	More  bool               `json:"more"` // a later page has sheets
+}
@@ -1,27 +1,17 @@
 package utils
 
 import (
-	"log"
 	"path/filepath"
+	"regexp"
 	"strings"
-
-	"github.com/go-git/go-git/v5"
 )
 
-func GetDestinationPath(filename string) (dest string, fname string) {
-	ext := filepath.Ext(filename) // file extension
-	name := strings.TrimSuffix(filename, ext)
-	fname = name + "_final"
-	finalName := fname + ext
-
-	return filepath.Join("out", finalName), fname
-}
-
-func GetRepoNameFromPath(path string) string {
-	_, repoName := filepath.Split(path)
+var repoSlugRegex = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
 
-	repoName = strings.TrimSuffix(repoName, ".git")
-	return repoName
+// GetSheetID names a stored sheet after its uploaded file, eg:
+// "1725815494_repo_6448_log.csv" -> "1725815494_repo_6448_log_final"
+func GetSheetID(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "_final"
 }
 
 func GetRepoNameFromFileName(filename string) string {
@@ -37,16 +27,11 @@ func GetRepoNameFromFileName(filename string) string {
 	return ""
 }
 
-func GetRepoNameFromRepo(repo *git.Repository) string {
-	worktree, err := repo.Worktree()
-	if err != nil {
-		log.Fatal("Error getting worktree:", err)
+// RepoSlug makes a repository name safe for a sheet file name, so it can be
+// read back by GetRepoNameFromFileName, eg: "My Repo_v2" -> "My-Repo-v2"
+func RepoSlug(name string) string {
+	if slug := strings.Trim(repoSlugRegex.ReplaceAllString(name, "-"), "-."); slug != "" {
+		return slug
 	}
-
-	repoDir := worktree.Filesystem.Root()
-
-	_, repoName := filepath.Split(repoDir)
-
-	repoName = strings.TrimSuffix(repoName, ".git")
-	return repoName
+	return "repo"
 }
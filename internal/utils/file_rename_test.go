@@ -0,0 +1,40 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/utils"
+)
+
+func TestRepoSlug(t *testing.T) {
+	tests := map[string]string{
+		"sheet-happens":      "sheet-happens",
+		"sheet_happens":      "sheet-happens",
+		"My Repo (v2)":       "My-Repo-v2",
+		"api.v1":             "api.v1",
+		"..hidden":           "hidden",
+		"répertoire":         "r-pertoire",
+		"%2e%2e":             "2e-2e",
+		"":                   "repo",
+		"__":                 "repo",
+		"repo/../../etc":     "repo-..-..-etc",
+		"sheet happens.git ": "sheet-happens.git",
+	}
+
+	for name, want := range tests {
+		got := utils.RepoSlug(name)
+		if got != want {
+			t.Errorf("RepoSlug(%q) = %q, want %q", name, got, want)
+		}
+
+		// the sheet named after it can be stored and read back
+		fileName := "1725815494_" + got + "_6448_log.csv"
+		if id := utils.GetSheetID(fileName); !services.ValidSheetID(id) {
+			t.Errorf("sheet id %q of %q is invalid", id, name)
+		}
+		if repo := utils.GetRepoNameFromFileName(fileName); repo != got {
+			t.Errorf("repo read back as %q, want %q", repo, got)
+		}
+	}
+}
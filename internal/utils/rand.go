@@ -1,14 +1,28 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fmt"
-	"math/rand"
+	"math/big"
+	mrand "math/rand"
 	"time"
 )
 
 func Generate4DigitCode() string {
-	src := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(src)
+	src := mrand.NewSource(time.Now().UnixNano())
+	r := mrand.New(src)
 	code := r.Intn(9000) + 1000
 	return fmt.Sprintf("%04d", code)
 }
+
+// GenerateOTP returns a zero padded numeric code from a cryptographically
+// secure source, unlike Generate4DigitCode it is safe to use for auth
+func GenerateOTP(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
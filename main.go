@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/webpointsolutions/sheet-happens/internal/config"
 	"github.com/webpointsolutions/sheet-happens/internal/server"
+	"github.com/webpointsolutions/sheet-happens/internal/services"
+	"github.com/webpointsolutions/sheet-happens/internal/storage"
 )
 
 func init() {
@@ -13,6 +17,14 @@ func init() {
 }
 
 func main() {
+	store, err := storage.New(config.Env.Storage)
+	if err != nil {
+		log.Fatal("Failed to open storage:", err)
+	}
+	services.Store = store
+	services.Notifiers = services.NewNotifiers()
+	services.StartOutbox(context.Background(), config.Env.OutboxWorkers)
+
 	handler := server.NewServer()
 
 	fmt.Println("Lisening on port 8080")
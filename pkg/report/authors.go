@@ -0,0 +1,50 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var slugRegex = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// authorFilter matches patterns case-insensitively against "Name <email>",
+// like `git log --author`
+type authorFilter struct {
+	email   string   // the git user.email the sheet defaults to, matched exactly
+	include []string // empty = everyone
+	exclude []string
+}
+
+// included reports whether `sig` is one of the authors the sheet is for
+func (f authorFilter) included(sig object.Signature) bool {
+	if f.email != "" {
+		return strings.EqualFold(sig.Email, f.email)
+	}
+	return len(f.include) == 0 || matchesIdentity(sig, f.include)
+}
+
+// excluded reports whether `sig` was excluded, eg: a bot
+func (f authorFilter) excluded(sig object.Signature) bool {
+	return matchesIdentity(sig, f.exclude)
+}
+
+func matchesIdentity(sig object.Signature, patterns []string) bool {
+	identity := strings.ToLower(sig.Name + " <" + sig.Email + ">")
+	for _, pattern := range patterns {
+		if strings.Contains(identity, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorSlug turns `john.doe@webpoint.io` into `john.doe`, safe for sheet ids
+func AuthorSlug(email string) string {
+	name, _, _ := strings.Cut(email, "@")
+	if slug := strings.Trim(slugRegex.ReplaceAllString(name, "-"), "-."); slug != "" {
+		return slug
+	}
+	return "unknown"
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// columns added with Options.HashColumn, ShortHashColumn and BranchColumn
+const (
+	BranchColumn    = "Branch"
+	HashColumn      = "Commit Hash"
+	ShortHashColumn = "Short Hash"
+)
+
+// branches checked before the others when a commit is on several of them
+var mainlineBranches = []string{"main", "master", "trunk", "develop"}
+
+// refTip is a ref a commit can be attributed to
+type refTip struct {
+	name   string
+	hash   plumbing.Hash
+	remote bool
+}
+
+// attributeBranches names the branch that introduced each of `commits`,
+// walking from the sheet's `refs`. The mainline and current branches go first
+// and claim their first-parent history, then the other branches, tags and refs
+// claim theirs. The commits a merge brought in are claimed by the branch named
+// in its message, eg: PROJ-7-login for "Merge pull request #12 from
+// org/PROJ-7-login", which may no longer exist. The walk ends once every one
+// of `commits` is claimed, and each ref's walk at its first commit older than
+// `since`, like walkLog.
+func attributeBranches(ctx context.Context, repo *git.Repository, refs []*plumbing.Reference, since time.Time, commits []*object.Commit) (map[plumbing.Hash]string, error) {
+	tips, err := branchTips(repo, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	unclaimed := make(map[plumbing.Hash]bool, len(commits))
+	for _, c := range commits {
+		unclaimed[c.Hash] = true
+	}
+
+	claimed := make(map[plumbing.Hash]string)
+	for len(tips) > 0 && len(unclaimed) > 0 {
+		tip := tips[0]
+		tips = tips[1:]
+
+		// first-parent walk until history claimed by an earlier ref
+		hash := tip.hash
+		for !hash.IsZero() && claimed[hash] == "" {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			c, err := repo.CommitObject(hash)
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				break // cut off by a shallow clone
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !since.IsZero() && c.Committer.When.Before(since) {
+				break
+			}
+			claimed[hash] = tip.name
+			delete(unclaimed, hash)
+
+			// the merged side is queued behind every branch and tag tip
+			for _, parent := range c.ParentHashes[min(1, len(c.ParentHashes)):] {
+				name := mergedBranch(c.Message)
+				if name == "" {
+					name = tip.name
+				}
+				tips = append(tips, refTip{name: name, hash: parent})
+			}
+
+			hash = plumbing.ZeroHash
+			if len(c.ParentHashes) > 0 {
+				hash = c.ParentHashes[0]
+			}
+		}
+	}
+
+	return claimed, nil
+}
+
+// branchTips returns the branches of `refs`, the mainline and current ones
+// first, followed by the tags and any other refs by name, annotated tags
+// peeled to their commit
+func branchTips(repo *git.Repository, refs []*plumbing.Reference) ([]refTip, error) {
+	var current string
+	if head, err := repo.Head(); err == nil && head.Name().IsBranch() {
+		current = head.Name().Short()
+	}
+
+	var branches, others []refTip
+	for _, ref := range refs {
+		name := ref.Name()
+		if name.IsBranch() || name.IsRemote() {
+			branches = append(branches, refTip{name: name.Short(), hash: ref.Hash(), remote: name.IsRemote()})
+			continue
+		}
+
+		hash, err := peelCommit(repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		if !hash.IsZero() {
+			others = append(others, refTip{name: name.Short(), hash: hash})
+		}
+	}
+
+	// a feature branch checked out would otherwise claim the mainline history
+	// it was branched from, origin/main ranks right after main
+	rank := func(tip refTip) int {
+		name := tip.name
+		if tip.remote {
+			_, name, _ = strings.Cut(name, "/") // origin/main -> main
+		}
+		r := len(mainlineBranches) + 1
+		if i := slices.Index(mainlineBranches, name); i >= 0 {
+			r = i
+		} else if name == current {
+			r = len(mainlineBranches)
+		}
+		if tip.remote {
+			return 2*r + 1
+		}
+		return 2 * r
+	}
+	slices.SortStableFunc(branches, func(a, b refTip) int {
+		if d := rank(a) - rank(b); d != 0 {
+			return d
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	slices.SortStableFunc(others, func(a, b refTip) int { return strings.Compare(a.name, b.name) })
+
+	return append(branches, others...), nil
+}
+
+// mergedBranch returns the branch named in a merge message, without the fork
+// owner of a pull request, or "" when the message isn't one git or a forge writes
+func mergedBranch(message string) string {
+	match := mergeSourceRegex.FindStringSubmatch(commitSubject(message))
+	switch {
+	case match == nil:
+		return ""
+	case match[2] != "":
+		_, branch, ok := strings.Cut(match[2], "/")
+		if !ok {
+			return match[2]
+		}
+		return branch
+	default:
+		return match[3]
+	}
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestGenerateBranchAttribution(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("Dev <dev@example.com>", "feat: base work")
+
+	// merged through a pull request, the branch deleted afterwards
+	r.checkout("PROJ-9-export", true)
+	export := r.commit("Dev <dev@example.com>", "feat: export button")
+	r.checkout("main", false)
+	merge := r.merge("PROJ-9-export", "Merge pull request #12 from someone/PROJ-9-export")
+	r.deleteBranch("PROJ-9-export")
+
+	// only reachable from a tag
+	r.checkout("hotfix", true)
+	hotfix := r.commit("Dev <dev@example.com>", "fix: hotfix")
+	head, err := r.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.repo.CreateTag("v1.0.1", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	r.checkout("main", false)
+	r.deleteBranch("hotfix")
+
+	// checked out, so ranked first among the feature branches but after main
+	r.checkout("PROJ-10-search", true)
+	search := r.commit("Dev <dev@example.com>", "feat: search box")
+
+	records := byHash(r.generate(t, Options{BranchColumn: true, Tags: true}))
+
+	want := map[plumbing.Hash]string{
+		base:   "main",
+		merge:  "main",
+		export: "PROJ-9-export",
+		hotfix: "v1.0.1",
+		search: "PROJ-10-search",
+	}
+	for hash, branch := range want {
+		rec, ok := records[hash]
+		if !ok {
+			t.Fatalf("%s is missing", shortHash(hash))
+		}
+		if rec.Branch != branch {
+			t.Errorf("%q is on %q, want %q", rec.Description, rec.Branch, branch)
+		}
+	}
+}
+
+func TestAttributeBranchesStopsAtSince(t *testing.T) {
+	r := newTestRepo(t)
+	var old []plumbing.Hash
+	for range 5 {
+		old = append(old, r.commit("Dev <dev@example.com>", "feat: old work"))
+	}
+	since := r.clock
+	recent := r.commit("Dev <dev@example.com>", "feat: recent work")
+
+	// never merged, so main is walked without claiming it
+	r.checkout("PROJ-11-draft", true)
+	draft := r.commit("Dev <dev@example.com>", "feat: draft")
+	r.checkout("main", false)
+
+	filter, err := newRefFilter(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := sheetRefs(r.repo, "", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commits []*object.Commit
+	for _, hash := range []plumbing.Hash{recent, draft} {
+		c, err := r.repo.CommitObject(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, c)
+	}
+
+	claimed, err := attributeBranches(context.Background(), r.repo, refs, since, commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claimed[recent] != "main" || claimed[draft] != "PROJ-11-draft" {
+		t.Errorf("recent work on %q and draft on %q, want main and PROJ-11-draft", claimed[recent], claimed[draft])
+	}
+	for _, hash := range old {
+		if name, ok := claimed[hash]; ok {
+			t.Errorf("%s before the range was walked and claimed by %q", shortHash(hash), name)
+		}
+	}
+}
+
+func TestMergedBranch(t *testing.T) {
+	tests := map[string]string{
+		"Merge pull request #12 from org/PROJ-7-login":    "PROJ-7-login",
+		"Merge pull request #13 from feature":             "feature",
+		"Merge branch 'PROJ-8-search' into main":          "PROJ-8-search",
+		"Merge remote-tracking branch 'origin/PROJ-9'":    "origin/PROJ-9",
+		"feat: not a merge message":                       "",
+		"Merged PROJ-7 by hand\n\nMerge branch 'ignored'": "",
+	}
+	for message, want := range tests {
+		if got := mergedBranch(message); got != want {
+			t.Errorf("mergedBranch(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DiffColumns are added to the sheet when Options.DiffStats is set
+var DiffColumns = []string{"Lines Added", "Lines Removed", "Files Changed", "Directories"}
+
+// DiffStats is what a commit changed compared to its first parent
+type DiffStats struct {
+	Added   int
+	Removed int
+	Files   int
+	Dirs    []string // top-level directories touched, "." for files in the root
+
+	paths []string // every path added, removed or renamed from or to
+}
+
+// commitDiff diffs `c` against its first parent, or an empty tree for a root
+// commit or one whose parent was cut off by a shallow clone, as git does.
+// Line counts are skipped when `lines` is false. A tree missing from a
+// partial clone returns nil, missing blobs leave the line counts at 0.
+func commitDiff(ctx context.Context, c *object.Commit, lines bool) (*DiffStats, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, ignoreMissing(err)
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err == nil {
+			parentTree, err = parent.Tree()
+		}
+		if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, ignoreMissing(err)
+	}
+
+	stats := &DiffStats{Files: len(changes)}
+	seen := map[string]struct{}{}
+	dirs := map[string]struct{}{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if _, ok := seen[name]; name == "" || ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			stats.paths = append(stats.paths, name)
+
+			dir := topLevelDir(name)
+			if _, ok := dirs[dir]; !ok {
+				dirs[dir] = struct{}{}
+				stats.Dirs = append(stats.Dirs, dir)
+			}
+		}
+	}
+	slices.Sort(stats.Dirs)
+
+	if !lines {
+		return stats, nil
+	}
+
+	patch, err := changes.PatchContext(ctx)
+	if err != nil {
+		return stats, ignoreMissing(err)
+	}
+	for _, file := range patch.Stats() {
+		stats.Added += file.Addition
+		stats.Removed += file.Deletion
+	}
+
+	return stats, nil
+}
+
+// Touches reports whether the commit changed anything under one of `paths`.
+// Unknown stats touch everything, a commit isn't dropped for what a partial
+// clone left out.
+func (s *DiffStats) Touches(paths []string) bool {
+	if s == nil {
+		return true
+	}
+	for _, p := range s.paths {
+		for _, prefix := range paths {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cleanPaths normalises --paths values, eg: ./services/api/ to services/api.
+// An empty result means the whole tree.
+func cleanPaths(paths []string) []string {
+	var cleaned []string
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "/"))
+		if p != "." && !slices.Contains(cleaned, p) {
+			cleaned = append(cleaned, p)
+		}
+	}
+	return cleaned
+}
+
+func topLevelDir(p string) string {
+	dir, _, ok := strings.Cut(p, "/")
+	if !ok {
+		return "."
+	}
+	return dir
+}
+
+// ignoreMissing treats objects left out of a shallow or partial clone as
+// unknown rather than an error
+func ignoreMissing(err error) error {
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFiles writes `files` and commits them as Dev, an empty content
+// removes the file
+func (r *testRepo) commitFiles(message string, files map[string]string) {
+	r.t.Helper()
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	for name, content := range files {
+		if content == "" {
+			if _, err := wt.Remove(name); err != nil {
+				r.t.Fatal(err)
+			}
+			continue
+		}
+
+		full := filepath.Join(r.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	signature := &object.Signature{Name: "Dev", Email: "dev@example.com", When: r.clock}
+	r.clock = r.clock.Add(r.step)
+	if _, err := wt.Commit(message, &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func TestGenerateDiffStats(t *testing.T) {
+	tests := map[string]struct {
+		change map[string]string
+		want   DiffStats
+	}{
+		"add nested file": {
+			change: map[string]string{"services/api/main.go": "x\ny\n"},
+			want:   DiffStats{Added: 2, Files: 1, Dirs: []string{"services"}},
+		},
+		"edit root file": {
+			change: map[string]string{"README.md": "a\nc\n"},
+			want:   DiffStats{Added: 1, Removed: 1, Files: 1, Dirs: []string{"."}},
+		},
+		"delete": {
+			change: map[string]string{"docs/guide.md": ""},
+			want:   DiffStats{Removed: 1, Files: 1, Dirs: []string{"docs"}},
+		},
+		"rename": {
+			change: map[string]string{"docs/guide.md": "", "guides/guide.md": "one\n"},
+			want:   DiffStats{Files: 1, Dirs: []string{"docs", "guides"}},
+		},
+		"several directories": {
+			change: map[string]string{"services/a.go": "1\n", "services/b.go": "2\n", "docs/faq.md": "3\n"},
+			want:   DiffStats{Added: 3, Files: 3, Dirs: []string{"docs", "services"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commitFiles("feat: base", map[string]string{"README.md": "a\nb\n", "docs/guide.md": "one\n"})
+			r.commitFiles("feat: change", tt.change)
+
+			got := byDescription(r.generate(t, Options{DiffStats: true}))["change"].Stats
+			if got == nil {
+				t.Fatal("no stats")
+			}
+			if got.Added != tt.want.Added || got.Removed != tt.want.Removed || got.Files != tt.want.Files || !slices.Equal(got.Dirs, tt.want.Dirs) {
+				t.Errorf("stats = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStatsTouches(t *testing.T) {
+	stats := &DiffStats{paths: []string{"services/api/main.go", "docs/guide.md"}}
+
+	tests := map[string]struct {
+		stats *DiffStats
+		paths []string
+		want  bool
+	}{
+		"directory":           {stats: stats, paths: []string{"services"}, want: true},
+		"nested directory":    {stats: stats, paths: []string{"services/api"}, want: true},
+		"file":                {stats: stats, paths: []string{"docs/guide.md"}, want: true},
+		"prefix of a name":    {stats: stats, paths: []string{"services/ap"}},
+		"other directory":     {stats: stats, paths: []string{"web"}},
+		"any of several":      {stats: stats, paths: []string{"web", "docs"}, want: true},
+		"unknown stats":       {paths: []string{"web"}, want: true},
+		"nothing changed":     {stats: &DiffStats{}, paths: []string{"services"}},
+		"file as a directory": {stats: stats, paths: []string{"docs/guide.md/x"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.stats.Touches(tt.paths); got != tt.want {
+				t.Errorf("Touches(%q) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePaths(t *testing.T) {
+	r := newTestRepo(t)
+	r.commitFiles("feat: api", map[string]string{"services/api/main.go": "x\n"})
+	r.commitFiles("feat: web", map[string]string{"services/web/app.js": "y\n"})
+	r.commitFiles("docs: guide", map[string]string{"docs/guide.md": "z\n"})
+	r.commitFiles("refactor: move api", map[string]string{"services/api/main.go": "", "api/main.go": "x\n"})
+
+	tests := map[string]struct {
+		paths []string
+		want  []string
+	}{
+		"whole tree":   {paths: []string{"."}, want: []string{"api", "guide", "move api", "web"}},
+		"subtree":      {paths: []string{"./services/api/"}, want: []string{"api", "move api"}},
+		"two subtrees": {paths: []string{"services/web", "docs"}, want: []string{"guide", "web"}},
+		"renamed to":   {paths: []string{"api"}, want: []string{"move api"}},
+		"windows path": {paths: []string{"services\\web"}, want: []string{"web"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := []string{}
+			for description := range byDescription(r.generate(t, Options{Paths: tt.paths})) {
+				got = append(got, description)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %s, want %s", strings.Join(got, ", "), strings.Join(tt.want, ", "))
+			}
+		})
+	}
+
+	_, err := Generate(context.Background(), Options{Folders: []string{r.dir}, AllAuthors: true, Paths: []string{"web"}})
+	if !errors.Is(err, ErrNoCommits) {
+		t.Errorf("untouched path: got %v, want ErrNoCommits", err)
+	}
+}
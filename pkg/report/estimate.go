@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// a gap longer than this between two commits of an author ends a work session
+	DefaultIdleThreshold = 2 * time.Hour
+	// time credited to the first commit of a session, the work before it has no earlier commit to measure from
+	DefaultFirstCommit = 30 * time.Minute
+)
+
+// EstimateOptions are used as given, a zero FirstCommit credits nothing to
+// the first commit of a session and a zero IdleThreshold ends a session at
+// every gap
+type EstimateOptions struct {
+	IdleThreshold time.Duration
+	FirstCommit   time.Duration
+}
+
+// DefaultEstimate is used when Options.Estimate is nil
+var DefaultEstimate = EstimateOptions{IdleThreshold: DefaultIdleThreshold, FirstCommit: DefaultFirstCommit}
+
+// WorkItem is a commit as seen by the estimator
+type WorkItem struct {
+	Author string // identity sessions are grouped by, eg: an email
+	When   time.Time
+
+	Explicit time.Duration // tracked by the author, used instead of the estimate when set
+}
+
+// Estimate is the time credited to one WorkItem
+type Estimate struct {
+	Duration        time.Duration
+	Explicit        bool // Duration was tracked, not estimated
+	Session         int  // numbered per author from 1, oldest first
+	SessionDuration time.Duration
+}
+
+// EstimateSessions groups the items of each author into work sessions and
+// returns one Estimate per item, in the order of `items`. Every commit after
+// the first of a session is credited with the gap to the previous one, unless
+// its time was tracked explicitly.
+func EstimateSessions(items []WorkItem, opts EstimateOptions) []Estimate {
+	idle, first := opts.IdleThreshold, opts.FirstCommit
+
+	byAuthor := make(map[string][]int)
+	for i, item := range items {
+		key := strings.ToLower(item.Author)
+		byAuthor[key] = append(byAuthor[key], i)
+	}
+
+	estimates := make([]Estimate, len(items))
+	for _, indexes := range byAuthor {
+		slices.SortStableFunc(indexes, func(a, b int) int { return items[a].When.Compare(items[b].When) })
+
+		session, start := 0, 0
+		for n, i := range indexes {
+			if n == 0 || items[i].When.Sub(items[indexes[n-1]].When) > idle {
+				closeSession(estimates, indexes[start:n])
+				session++
+				start = n
+				estimates[i] = Estimate{Duration: first, Session: session}
+			} else {
+				estimates[i] = Estimate{Duration: items[i].When.Sub(items[indexes[n-1]].When), Session: session}
+			}
+
+			if items[i].Explicit > 0 {
+				estimates[i].Duration, estimates[i].Explicit = items[i].Explicit, true
+			}
+		}
+		closeSession(estimates, indexes[start:])
+	}
+
+	return estimates
+}
+
+// closeSession sets the session total on every estimate of the session
+func closeSession(estimates []Estimate, session []int) {
+	var total time.Duration
+	for _, i := range session {
+		total += estimates[i].Duration
+	}
+	for _, i := range session {
+		estimates[i].SessionDuration = total
+	}
+}
+
+// estimateSource is the Source column of `e`
+func estimateSource(e Estimate) string {
+	if e.Explicit {
+		return "explicit"
+	}
+	return "estimated"
+}
+
+// formatDuration formats `d` as shown in the TimeStamp column, eg: "1h 5m" or "45m"
+func formatDuration(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+// formatHours formats `d` as decimal hours, eg: "1.25"
+func formatHours(d time.Duration) string {
+	return fmt.Sprintf("%.2f", d.Hours())
+}
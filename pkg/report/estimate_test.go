@@ -0,0 +1,159 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEstimateSessions(t *testing.T) {
+	start := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := map[string]struct {
+		items []WorkItem
+		opts  EstimateOptions
+		want  []Estimate
+	}{
+		"one commit": {
+			items: []WorkItem{{Author: "a", When: at(0)}},
+			opts:  DefaultEstimate,
+			want:  []Estimate{{Duration: 30 * time.Minute, Session: 1, SessionDuration: 30 * time.Minute}},
+		},
+		"gaps within a session": {
+			items: []WorkItem{{Author: "a", When: at(0)}, {Author: "a", When: at(45)}, {Author: "a", When: at(60)}},
+			opts:  DefaultEstimate,
+			want: []Estimate{
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 90 * time.Minute},
+				{Duration: 45 * time.Minute, Session: 1, SessionDuration: 90 * time.Minute},
+				{Duration: 15 * time.Minute, Session: 1, SessionDuration: 90 * time.Minute},
+			},
+		},
+		"idle gap starts a session": {
+			items: []WorkItem{{Author: "a", When: at(0)}, {Author: "a", When: at(121)}},
+			opts:  DefaultEstimate,
+			want: []Estimate{
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 30 * time.Minute},
+				{Duration: 30 * time.Minute, Session: 2, SessionDuration: 30 * time.Minute},
+			},
+		},
+		"gap of exactly the threshold": {
+			items: []WorkItem{{Author: "a", When: at(0)}, {Author: "a", When: at(120)}},
+			opts:  DefaultEstimate,
+			want: []Estimate{
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 150 * time.Minute},
+				{Duration: 120 * time.Minute, Session: 1, SessionDuration: 150 * time.Minute},
+			},
+		},
+		"authors apart, newest first": {
+			items: []WorkItem{{Author: "b", When: at(20)}, {Author: "A", When: at(10)}, {Author: "a", When: at(0)}},
+			opts:  DefaultEstimate,
+			want: []Estimate{
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 30 * time.Minute},
+				{Duration: 10 * time.Minute, Session: 1, SessionDuration: 40 * time.Minute},
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 40 * time.Minute},
+			},
+		},
+		"explicit time wins": {
+			items: []WorkItem{{Author: "a", When: at(0)}, {Author: "a", When: at(10), Explicit: 2 * time.Hour}},
+			opts:  DefaultEstimate,
+			want: []Estimate{
+				{Duration: 30 * time.Minute, Session: 1, SessionDuration: 150 * time.Minute},
+				{Duration: 2 * time.Hour, Explicit: true, Session: 1, SessionDuration: 150 * time.Minute},
+			},
+		},
+		"zero options": {
+			items: []WorkItem{{Author: "a", When: at(0)}, {Author: "a", When: at(10)}, {Author: "a", When: at(10)}},
+			opts:  EstimateOptions{},
+			want: []Estimate{
+				{Session: 1},
+				{Session: 2},
+				{Session: 2},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := EstimateSessions(tt.items, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d estimates, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("estimate %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateSkipsDropped(t *testing.T) {
+	start := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	records := []Record{
+		{AuthorEmail: "dev@example.com", Date: start.Add(time.Hour)},
+		{AuthorEmail: "dev@example.com", Date: start.Add(30 * time.Minute), Dropped: "merge"},
+		{AuthorEmail: "dev@example.com", Date: start},
+	}
+
+	estimate(records, DefaultEstimate)
+
+	if records[1].Estimate != (Estimate{}) {
+		t.Errorf("dropped record got %+v", records[1].Estimate)
+	}
+	if records[0].Estimate.Duration != time.Hour || records[0].Estimate.SessionDuration != 90*time.Minute {
+		t.Errorf("the dropped record split the session: %+v", records[0].Estimate)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                               "0m",
+		45 * time.Minute:                "45m",
+		time.Hour:                       "1h 0m",
+		65*time.Minute + 30*time.Second: "1h 5m",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+
+	if got := formatHours(75 * time.Minute); got != "1.25" {
+		t.Errorf("formatHours(75m) = %q, want 1.25", got)
+	}
+}
+
+func TestGenerateEstimateOptions(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work")
+	r.commit("Dev <dev@example.com>", "feat: more work")
+
+	tests := map[string]struct {
+		estimate *EstimateOptions
+		want     time.Duration // of the sheet
+	}{
+		"defaults":          {want: 60 * time.Minute},
+		"explicit zero":     {estimate: &EstimateOptions{IdleThreshold: DefaultIdleThreshold}, want: 30 * time.Minute},
+		"longer first":      {estimate: &EstimateOptions{IdleThreshold: DefaultIdleThreshold, FirstCommit: time.Hour}, want: 90 * time.Minute},
+		"every gap is idle": {estimate: &EstimateOptions{FirstCommit: time.Hour}, want: 2 * time.Hour},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var total time.Duration
+			for _, rec := range r.generate(t, Options{Estimate: tt.estimate}).Records {
+				total += rec.Estimate.Duration
+			}
+			if total != tt.want {
+				t.Errorf("sheet total = %s, want %s", total, tt.want)
+			}
+		})
+	}
+
+	_, err := Generate(context.Background(), Options{Folders: []string{r.dir}, AllAuthors: true, Estimate: &EstimateOptions{FirstCommit: -time.Minute}})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("negative first commit time: got %v, want ErrInvalidOption", err)
+	}
+}
@@ -0,0 +1,518 @@
+// Package report builds timesheets from git history. Generate walks a
+// repository and returns a Report, the writers render it and the uploaders
+// send it to a Sheet Happens server.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// eg: feat(auth): impliment 2fa and token refresh cycle
+var semanticRegex = regexp.MustCompile(`^(?P<type>\w+)(\((?P<scope>[^)]+)\))?: (?P<description>.+)$`)
+
+var (
+	ErrNoCommits = errors.New("no commits matched the given filters")
+	ErrNoAuthor  = errors.New("no git user.email configured, pass an author or set AllAuthors")
+
+	ErrInvalidOption = errors.New("invalid option")
+)
+
+type Options struct {
+	Folders     []string // repositories, or directories to scan for them (empty = current directory)
+	Remotes     []string // repository URLs, cloned into memory
+	RemoteAuth  RemoteAuth
+	CloneDepth  int    // commits fetched per remote branch (0 = full history)
+	Branch      string // filters commits from a specific branch (empty = all branches)
+	SinceDays   int    // filters commits since given number of days ago (0 = no time filter), instead of Range.Since
+	Range       DateRange
+	Period      string // name of the period Range was resolved from, if any
+	Description string // free text sent along with the uploaded sheet
+
+	RemoteBranches bool     // also walks refs/remotes/*
+	Tags           bool     // also walks refs/tags/*
+	Refs           []string // also walks the refs matching these globs, eg: refs/pull/*
+	ExcludeRefs    []string // never walks the refs matching these globs, eg: origin/dependabot/*
+
+	Estimate *EstimateOptions // how commit time is turned into hours (nil = DefaultEstimate)
+	TimeKeys []string         // trailer keys holding tracked time (empty = Time-Spent)
+	NotesRef string           // git notes read for tracked time (empty = refs/notes/commits)
+
+	TicketPatterns []string // regexps matching ticket keys (empty = DefaultTicketPatterns)
+
+	HashColumn      bool // adds the full commit hash
+	ShortHashColumn bool // adds the abbreviated commit hash
+	BranchColumn    bool // adds the branch that introduced each commit
+
+	DiffStats bool     // adds lines added and removed, files changed and directories touched
+	Paths     []string // keeps commits touching any of these subtrees (empty = whole tree)
+
+	Merges          string   // MergesKeep (default), MergesDrop or MergesCollapse
+	Reverts         string   // RevertsKeep (default), RevertsPair or RevertsDrop
+	ExcludeMessages []string // regexps, drops commits whose message matches any
+	ExcludeBots     bool     // drops commits by BotAuthors
+	ShowDropped     bool     // keeps dropped commits as rows without hours, the reason in DroppedColumn
+
+	Authors        []string // keeps commits whose "Name <email>" contains any of these (empty = git config user.email)
+	ExcludeAuthors []string // drops commits whose "Name <email>" contains any of these, eg: bots
+	AllAuthors     bool     // keeps every author when Authors is empty
+}
+
+// Generate walks the repositories in `opts.Folders` and `opts.Remotes` and
+// returns their commits as one Report
+func Generate(ctx context.Context, opts Options) (*Report, error) {
+	g, repos, err := newGenerator(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, r := range repos {
+		repoRecords, err := g.generateRepo(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		records = append(records, repoRecords...)
+	}
+
+	if len(records) == 0 {
+		return nil, ErrNoCommits
+	}
+
+	// newest first, estimated together so work in parallel repos isn't counted twice
+	slices.SortStableFunc(records, func(a, b Record) int { return b.Date.Compare(a.Date) })
+	estimate(records, g.estimate)
+
+	rep := g.report(repos)
+	rep.Records = records
+	return rep, nil
+}
+
+// generator holds what Generate shares between repositories
+type generator struct {
+	opts        Options
+	commitRange DateRange
+	estimate    EstimateOptions
+	tickets     *TicketMatcher
+	rules       *noiseRules
+	refs        *refFilter
+
+	// the same commit can be in several clones or forks, it counts once
+	seen map[plumbing.Hash]bool
+}
+
+// newGenerator checks `opts` and opens or clones its repositories
+func newGenerator(ctx context.Context, opts Options) (*generator, []namedRepo, error) {
+	tickets, err := NewTicketMatcher(opts.TicketPatterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.Paths = cleanPaths(opts.Paths)
+
+	// SinceDays is the start of the range, there can't be two
+	if opts.SinceDays < 0 || opts.SinceDays > 0 && !opts.Range.Since.IsZero() {
+		return nil, nil, fmt.Errorf("%w: SinceDays can't be negative or combined with Range.Since", ErrInvalidOption)
+	}
+
+	estimate := DefaultEstimate
+	if opts.Estimate != nil {
+		estimate = *opts.Estimate
+	}
+	if estimate.IdleThreshold < 0 || estimate.FirstCommit < 0 {
+		return nil, nil, fmt.Errorf("%w: idle threshold and first commit time can't be negative", ErrInvalidOption)
+	}
+
+	rules, err := newNoiseRules(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refs, err := newRefFilter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	folders := opts.Folders
+	if len(folders) == 0 && len(opts.Remotes) == 0 {
+		folders = []string{"."} // default to current directory
+	}
+
+	var repos []namedRepo
+	for _, folder := range folders {
+		found, err := openRepositories(folder)
+		if err != nil {
+			return nil, nil, err
+		}
+		repos = append(repos, found...)
+	}
+	for _, remote := range opts.Remotes {
+		r, err := cloneRemote(ctx, remote, opts.RemoteAuth, opts.CloneDepth)
+		if err != nil {
+			return nil, nil, err
+		}
+		repos = append(repos, r)
+	}
+
+	commitRange := opts.Range
+	if opts.SinceDays > 0 {
+		commitRange.Since = time.Now().AddDate(0, 0, -opts.SinceDays)
+	}
+
+	return &generator{
+		opts:        opts,
+		commitRange: commitRange,
+		estimate:    estimate,
+		tickets:     tickets,
+		rules:       rules,
+		refs:        refs,
+		seen:        make(map[plumbing.Hash]bool),
+	}, repos, nil
+}
+
+// report returns the Report of `repos` without its records
+func (g *generator) report(repos []namedRepo) *Report {
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.name)
+	}
+
+	return &Report{
+		Repo:            strings.Join(names, ", "),
+		Repos:           names,
+		Branch:          g.opts.Branch,
+		SinceDays:       g.opts.SinceDays,
+		Range:           g.commitRange,
+		Period:          g.opts.Period,
+		Description:     g.opts.Description,
+		GeneratedBy:     getGitUser(repos[0].repo),
+		GeneratedAt:     time.Now(),
+		HashColumn:      g.opts.HashColumn,
+		ShortHashColumn: g.opts.ShortHashColumn,
+		BranchColumn:    g.opts.BranchColumn,
+		DiffStats:       g.opts.DiffStats,
+		ShowDropped:     g.opts.ShowDropped,
+	}
+}
+
+// repoContext is what the records of one repository are built with
+type repoContext struct {
+	namedRepo
+	mailmap   *Mailmap
+	authors   authorFilter
+	timeSpent *TimeSpent
+}
+
+func (g *generator) openRepo(r namedRepo) (*repoContext, error) {
+	mailmap, err := LoadMailmap(r.repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .mailmap: %w", err)
+	}
+
+	authors := authorFilter{include: g.opts.Authors, exclude: g.opts.ExcludeAuthors}
+	if g.opts.ExcludeBots {
+		authors.exclude = append(slices.Clip(authors.exclude), BotAuthors...)
+	}
+	if len(authors.include) == 0 && !g.opts.AllAuthors {
+		email := getGitEmail(r.repo)
+		if email == "" {
+			return nil, ErrNoAuthor
+		}
+		// commits are matched on their .mailmap identity, the whole email so
+		// an@ doesn't also pick up dan@ and joan@
+		authors.email = mailmap.Resolve(object.Signature{Email: email}).Email
+	}
+
+	timeSpent, err := LoadTimeSpent(r.repo, g.opts.TimeKeys, g.opts.NotesRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git notes: %w", err)
+	}
+
+	return &repoContext{namedRepo: r, mailmap: mailmap, authors: authors, timeSpent: timeSpent}, nil
+}
+
+// generateRepo returns the records of one repository, skipping commits
+// already seen in another one
+func (g *generator) generateRepo(ctx context.Context, r namedRepo) ([]Record, error) {
+	repo, opts := r.repo, g.opts
+
+	rc, err := g.openRepo(r)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := sheetRefs(repo, opts.Branch, g.refs)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := collectCommits(ctx, repo, refs, g.commitRange)
+	if err != nil {
+		return nil, err
+	}
+	commits = slices.DeleteFunc(commits, func(c *object.Commit) bool {
+		duplicate := g.seen[c.Hash]
+		g.seen[c.Hash] = true
+		return duplicate
+	})
+
+	stats := make(map[plumbing.Hash]*DiffStats)
+	if opts.DiffStats || len(opts.Paths) > 0 {
+		for _, c := range commits {
+			if stats[c.Hash], err = commitDiff(ctx, c, opts.DiffStats); err != nil {
+				return nil, fmt.Errorf("failed to diff %s: %w", c.Hash, err)
+			}
+		}
+	}
+	if len(opts.Paths) > 0 {
+		commits = slices.DeleteFunc(commits, func(c *object.Commit) bool {
+			return !stats[c.Hash].Touches(opts.Paths)
+		})
+	}
+
+	tips, err := g.claimRefs(repo, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	branchTickets, err := g.tickets.FromRefs(repo, branchRefs(tips), g.commitRange.Since, commits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branches: %w", err)
+	}
+
+	noise, err := g.rules.apply(ctx, commits, g.tickets)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches map[plumbing.Hash]string
+	if opts.BranchColumn {
+		if branches, err = attributeBranches(ctx, repo, tips, g.commitRange.Since, commits); err != nil {
+			return nil, fmt.Errorf("failed to attribute branches: %w", err)
+		}
+	}
+
+	var records []Record
+	for _, c := range commits {
+		records = append(records, g.records(rc, c, commitInfo{
+			stats:         stats[c.Hash],
+			dropped:       noise.dropped[c.Hash],
+			note:          noise.notes[c.Hash],
+			branchTickets: branchTickets[c.Hash],
+			mergeTickets:  noise.tickets[c.Hash],
+			branch:        branches[c.Hash],
+		})...)
+	}
+
+	return records, nil
+}
+
+// commitInfo is what the rules and refs found out about a commit
+type commitInfo struct {
+	stats         *DiffStats
+	dropped       string // why the commit isn't counted
+	note          string // appended to the description
+	branchTickets []string
+	mergeTickets  []string // inherited from a collapsed merge
+	branch        string
+}
+
+// records returns the records of `c`, one for its author and one for each
+// co-author the author filter keeps
+func (g *generator) records(rc *repoContext, c *object.Commit, info commitInfo) []Record {
+	spent, _ := rc.timeSpent.Of(c)
+	commitType, scope, desc := parseSemanticCommit(commitSubject(c.Message))
+	if info.note != "" {
+		desc += " (" + info.note + ")"
+	}
+	ticketKeys := g.tickets.Find(append([]string{c.Message, scope}, info.branchTickets...)...)
+	for _, key := range info.mergeTickets {
+		if !slices.Contains(ticketKeys, key) {
+			ticketKeys = append(ticketKeys, key)
+		}
+	}
+
+	var records []Record
+	for _, author := range commitAuthors(c, rc.mailmap) {
+		if !rc.authors.included(author) {
+			continue
+		}
+		dropped := info.dropped
+		if dropped == "" && rc.authors.excluded(author) {
+			dropped = "excluded author"
+		}
+		if dropped != "" && !g.opts.ShowDropped {
+			continue
+		}
+		records = append(records, Record{
+			Repository:  rc.name,
+			Hash:        c.Hash.String(),
+			Branch:      info.branch,
+			Date:        c.Author.When,
+			Author:      author.Name,
+			AuthorEmail: author.Email,
+			Type:        commitType,
+			Scope:       scope,
+			Description: desc,
+			Tickets:     ticketKeys,
+			TimeSpent:   spent,
+			Stats:       info.stats,
+			Dropped:     dropped,
+		})
+	}
+
+	return records
+}
+
+// collectCommits returns the commits reachable from `refs` and authored
+// within `commitRange`, newest commit time first and each once
+func collectCommits(ctx context.Context, repo *git.Repository, refs []*plumbing.Reference, commitRange DateRange) ([]*object.Commit, error) {
+	walker := newCommitWalker(commitRange.Since)
+	defer walker.Close()
+
+	if err := walker.add(repo, refs, 0); err != nil {
+		return nil, err
+	}
+
+	var commits []*object.Commit
+	for {
+		c, _, _, err := walker.next(ctx)
+		if err == io.EOF {
+			return commits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if commitRange.Contains(c.Author.When) {
+			commits = append(commits, c)
+		}
+	}
+}
+
+// sheetRefs returns the refs the sheet is walked from: the -b branch, or
+// every ref the filter picks
+func sheetRefs(repo *git.Repository, branch string, refs *refFilter) ([]*plumbing.Reference, error) {
+	if branch == "" {
+		return refs.selectRefs(repo)
+	}
+
+	ref, err := findRef(repo, branch)
+	if err != nil {
+		return nil, fmt.Errorf("branch %s not found: %w", branch, err)
+	}
+	return []*plumbing.Reference{ref}, nil
+}
+
+// claimRefs returns the refs that claim the commits of the sheet walked from
+// `refs`, for their branch tickets and branch column. With -b the other
+// branches are added, the mainline still claims the history the branch
+// started from.
+func (g *generator) claimRefs(repo *git.Repository, refs []*plumbing.Reference) ([]*plumbing.Reference, error) {
+	if g.opts.Branch == "" {
+		return refs, nil
+	}
+
+	others, err := g.refs.selectRefs(repo)
+	if err != nil {
+		return nil, err
+	}
+	others = slices.DeleteFunc(others, func(other *plumbing.Reference) bool {
+		return slices.ContainsFunc(refs, func(ref *plumbing.Reference) bool { return ref.Name() == other.Name() })
+	})
+	return append(slices.Clip(refs), others...), nil
+}
+
+// walkLog calls `fn` for every commit reachable from `from`, newest commit
+// time first, and stops at the first commit older than `since` when it is
+// set. History cut short by a shallow or partial clone ends the walk instead
+// of failing it.
+func walkLog(repo *git.Repository, from plumbing.Hash, since time.Time, fn func(c *object.Commit) error) error {
+	iter, err := repo.Log(&git.LogOptions{From: from, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		// commits are authored before they are committed, as in commitWalker
+		if !since.IsZero() && c.Committer.When.Before(since) {
+			return storer.ErrStop
+		}
+		return fn(c)
+	})
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// getGitUser returns the user configured for the repository, falling back
+// to the global and system git config. A repository cloned into memory has
+// no user of its own and returns "", the caller knows who asked for it.
+func getGitUser(repo *git.Repository) string {
+	if _, ok := repo.Storer.(*memory.Storage); ok {
+		return ""
+	}
+
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	switch {
+	case cfg.User.Name != "" && cfg.User.Email != "":
+		return fmt.Sprintf("%s <%s>", cfg.User.Name, cfg.User.Email)
+	case cfg.User.Email != "":
+		return cfg.User.Email
+	case cfg.User.Name != "":
+		return cfg.User.Name
+	default:
+		return os.Getenv("USER")
+	}
+}
+
+// getGitEmail returns the user.email of the repository, falling back to
+// the global and system git config
+func getGitEmail(repo *git.Repository) string {
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return ""
+	}
+	return cfg.User.Email
+}
+
+// commitSubject returns the first line of a commit message, the body and
+// trailers such as Co-authored-by are not part of the sheet
+func commitSubject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(subject)
+}
+
+func parseSemanticCommit(message string) (commitType, scope, description string) {
+	matches := semanticRegex.FindStringSubmatch(message)
+	if matches == nil {
+		return "unknown", "", message
+	}
+
+	result := make(map[string]string)
+	for i, name := range semanticRegex.SubexpNames() {
+		if i != 0 && name != "" {
+			result[name] = matches[i]
+		}
+	}
+
+	return result["type"], result["scope"], result["description"]
+}
@@ -0,0 +1,277 @@
+package report
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testRepo builds a repository on disk, one commit every `step` from `clock`
+type testRepo struct {
+	t     *testing.T
+	dir   string
+	repo  *git.Repository
+	clock time.Time
+	step  time.Duration
+	files int
+}
+
+// newTestRepo initializes a repository whose default branch is main
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	return newTestRepoAt(t, t.TempDir())
+}
+
+// newTestRepoAt is newTestRepo in `dir`, the repository is named after it
+func newTestRepoAt(t *testing.T, dir string) *testRepo {
+	t.Helper()
+
+	repo, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testRepo{
+		t:     t,
+		dir:   dir,
+		repo:  repo,
+		clock: time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC),
+		step:  30 * time.Minute,
+	}
+}
+
+// commit adds a file and commits it as `author`, eg: "Dev <dev@example.com>"
+func (r *testRepo) commit(author, message string) plumbing.Hash {
+	r.t.Helper()
+
+	r.files++
+	name := "file" + strconv.Itoa(r.files) + ".txt"
+	if err := os.WriteFile(filepath.Join(r.dir, name), []byte(message), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		r.t.Fatal(err)
+	}
+
+	authorName, email, _ := strings.Cut(strings.TrimSuffix(author, ">"), " <")
+	signature := &object.Signature{Name: authorName, Email: email, When: r.clock}
+	r.clock = r.clock.Add(r.step)
+
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: signature, Committer: signature})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash
+}
+
+// merge commits a merge of `branch` into the current branch, keeping the
+// current tree
+func (r *testRepo) merge(branch, message string) plumbing.Hash {
+	r.t.Helper()
+
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	other, err := r.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	signature := &object.Signature{Name: "Dev", Email: "dev@example.com", When: r.clock}
+	r.clock = r.clock.Add(r.step)
+
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		Parents:           []plumbing.Hash{head.Hash(), other.Hash()},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash
+}
+
+// deleteBranch removes `branch`, as after merging a pull request
+func (r *testRepo) deleteBranch(branch string) {
+	r.t.Helper()
+
+	if err := r.repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(branch)); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// checkout switches to `branch`, creating it at HEAD when `create` is set
+func (r *testRepo) checkout(branch string, create bool) {
+	r.t.Helper()
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	err = wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: create})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// generate runs Generate over the repository, for every author unless
+// `opts` picks some
+func (r *testRepo) generate(t *testing.T, opts Options) *Report {
+	t.Helper()
+
+	opts.Folders = []string{r.dir}
+	opts.AllAuthors = len(opts.Authors) == 0
+	rep, err := Generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+// byDescription indexes the records of `rep` by their description
+func byDescription(rep *Report) map[string]Record {
+	records := make(map[string]Record)
+	for _, rec := range rep.Records {
+		records[rec.Description] = rec
+	}
+	return records
+}
+
+// ticketRepo has "base work" on main, PROJ-7-login branched from it and
+// "mainline fix" on main afterwards
+func ticketRepo(t *testing.T) *testRepo {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work")
+	r.checkout("PROJ-7-login", true)
+	r.commit("Dev <dev@example.com>", "feat(auth): login form")
+	r.checkout("main", false)
+	r.commit("Dev <dev@example.com>", "fix: mainline fix")
+	return r
+}
+
+func TestGenerateBranchTickets(t *testing.T) {
+	r := ticketRepo(t)
+
+	tests := map[string]struct {
+		branch string
+		want   map[string][]string
+	}{
+		"all branches": {
+			want: map[string][]string{
+				"base work":    nil,
+				"login form":   {"PROJ-7"},
+				"mainline fix": nil,
+			},
+		},
+		"ticket branch": {
+			branch: "PROJ-7-login",
+			want: map[string][]string{
+				"base work":  nil,
+				"login form": {"PROJ-7"},
+			},
+		},
+		"main": {
+			branch: "main",
+			want: map[string][]string{
+				"base work":    nil,
+				"mainline fix": nil,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			records := byDescription(r.generate(t, Options{Branch: tt.branch}))
+			if len(records) != len(tt.want) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.want))
+			}
+			for description, tickets := range tt.want {
+				rec, ok := records[description]
+				if !ok {
+					t.Fatalf("%q is missing", description)
+				}
+				if !slices.Equal(rec.Tickets, tickets) {
+					t.Errorf("%q has tickets %v, want %v", description, rec.Tickets, tickets)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateBranchColumn(t *testing.T) {
+	r := ticketRepo(t)
+
+	for _, branch := range []string{"", "PROJ-7-login"} {
+		t.Run("branch "+branch, func(t *testing.T) {
+			records := byDescription(r.generate(t, Options{Branch: branch, BranchColumn: true}))
+
+			want := map[string]string{"base work": "main", "login form": "PROJ-7-login"}
+			for description, branch := range want {
+				if got := records[description].Branch; got != branch {
+					t.Errorf("%q is on %q, want %q", description, got, branch)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRowsOldestFirst(t *testing.T) {
+	r := ticketRepo(t)
+	rep := r.generate(t, Options{})
+
+	rows := rep.Rows()
+	if !slices.Equal(rows[0], rep.Header()) {
+		t.Fatalf("first row = %v, want the header", rows[0])
+	}
+
+	descriptionCol := slices.Index(rows[0], "Description")
+	var got []string
+	for _, row := range rows[1:] {
+		got = append(got, row[descriptionCol])
+	}
+	if want := []string{"base work", "login form", "mainline fix"}; !slices.Equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateRangeColumn(t *testing.T) {
+	r := ticketRepo(t)
+
+	if header := r.generate(t, Options{}).Header(); slices.Contains(header, RangeColumn) {
+		t.Errorf("header %v has %s without a range", header, RangeColumn)
+	}
+
+	since := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	rows := r.generate(t, Options{Range: DateRange{Since: since, Until: since.AddDate(0, 1, 0)}}).Rows()
+	col := slices.Index(rows[0], RangeColumn)
+	if col < 0 {
+		t.Fatalf("header %v has no %s", rows[0], RangeColumn)
+	}
+	for _, row := range rows[1:] {
+		if row[col] != "2025-04-01 to 2025-04-30" {
+			t.Errorf("range cell = %q", row[col])
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// eg: Co-authored-by: Jane Doe <jane@webpoint.io>
+var coAuthorRegex = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// eg: Proper Name <proper@email> Commit Name <commit@email>
+var mailmapRegex = regexp.MustCompile(`^\s*([^<]*?)\s*<([^>]*)>\s*(?:([^<]*?)\s*<([^>]*)>)?\s*$`)
+
+// Mailmap maps the identities commits were made with to canonical ones, see
+// gitmailmap(5)
+type Mailmap struct {
+	entries []mailmapEntry
+}
+
+type mailmapEntry struct {
+	name, email             string // canonical, either may be empty
+	commitName, commitEmail string // commitName empty = any name
+}
+
+// LoadMailmap reads .mailmap from the worktree, or from HEAD in bare
+// repositories. A repository without one maps every identity to itself.
+func LoadMailmap(repo *git.Repository) (*Mailmap, error) {
+	if worktree, err := repo.Worktree(); err == nil {
+		file, err := os.Open(filepath.Join(worktree.Filesystem.Root(), ".mailmap"))
+		if errors.Is(err, os.ErrNotExist) {
+			return &Mailmap{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ParseMailmap(file)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return &Mailmap{}, nil // empty repository
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(".mailmap")
+	if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, plumbing.ErrObjectNotFound) {
+		return &Mailmap{}, nil // or left out of a partial clone
+	}
+	if err != nil {
+		return nil, err
+	}
+	reader, err := file.Reader()
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return &Mailmap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ParseMailmap(reader)
+}
+
+// ParseMailmap parses .mailmap lines, comments and malformed lines are skipped
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	m := &Mailmap{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		matches := mailmapRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		entry := mailmapEntry{name: matches[1], email: matches[2]}
+		if matches[4] != "" {
+			entry.commitName, entry.commitEmail = matches[3], matches[4]
+		} else {
+			// Proper Name <commit@email>
+			entry.email, entry.commitEmail = "", matches[2]
+		}
+		m.entries = append(m.entries, entry)
+	}
+
+	return m, scanner.Err()
+}
+
+// Resolve returns the canonical identity of `sig`. Entries that also match
+// the commit name win over the ones matching only the email.
+func (m *Mailmap) Resolve(sig object.Signature) object.Signature {
+	var match *mailmapEntry
+	for i, entry := range m.entries {
+		if !strings.EqualFold(entry.commitEmail, sig.Email) {
+			continue
+		}
+		if entry.commitName == "" && match == nil {
+			match = &m.entries[i]
+		}
+		if entry.commitName != "" && strings.EqualFold(entry.commitName, sig.Name) {
+			match = &m.entries[i]
+			break
+		}
+	}
+
+	if match != nil {
+		if match.name != "" {
+			sig.Name = match.name
+		}
+		if match.email != "" {
+			sig.Email = match.email
+		}
+	}
+	return sig
+}
+
+// commitAuthors returns the author of `c` followed by its Co-authored-by
+// trailers, all resolved through `mailmap` and without duplicates
+func commitAuthors(c *object.Commit, mailmap *Mailmap) []object.Signature {
+	authors := []object.Signature{mailmap.Resolve(c.Author)}
+
+	for _, matches := range coAuthorRegex.FindAllStringSubmatch(c.Message, -1) {
+		sig := mailmap.Resolve(object.Signature{Name: matches[1], Email: matches[2], When: c.Author.When})
+
+		duplicate := false
+		for _, author := range authors {
+			duplicate = duplicate || strings.EqualFold(author.Email, sig.Email)
+		}
+		if !duplicate {
+			authors = append(authors, sig)
+		}
+	}
+
+	return authors
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const testMailmap = `# canonical identities
+Jane Doe <jane@webpoint.io>
+<jane@webpoint.io> <jane.doe@old-laptop.local>
+John Smith <john@webpoint.io> <john@users.noreply.github.com>
+Build Bot <bot@webpoint.io> ci <ci@webpoint.io>
+not a mailmap line
+`
+
+func TestMailmapResolve(t *testing.T) {
+	m, err := ParseMailmap(strings.NewReader(testMailmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		sig  object.Signature
+		want object.Signature
+	}{
+		"proper name": {
+			sig:  object.Signature{Name: "jdoe", Email: "jane@webpoint.io"},
+			want: object.Signature{Name: "Jane Doe", Email: "jane@webpoint.io"},
+		},
+		"proper email": {
+			sig:  object.Signature{Name: "Jane", Email: "Jane.Doe@old-laptop.local"},
+			want: object.Signature{Name: "Jane", Email: "jane@webpoint.io"},
+		},
+		"name and email": {
+			sig:  object.Signature{Name: "johnny", Email: "john@users.noreply.github.com"},
+			want: object.Signature{Name: "John Smith", Email: "john@webpoint.io"},
+		},
+		"commit name must match": {
+			sig:  object.Signature{Name: "someone", Email: "ci@webpoint.io"},
+			want: object.Signature{Name: "someone", Email: "ci@webpoint.io"},
+		},
+		"commit name matches": {
+			sig:  object.Signature{Name: "CI", Email: "ci@webpoint.io"},
+			want: object.Signature{Name: "Build Bot", Email: "bot@webpoint.io"},
+		},
+		"unmapped": {
+			sig:  object.Signature{Name: "Dev", Email: "dev@example.com"},
+			want: object.Signature{Name: "Dev", Email: "dev@example.com"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := m.Resolve(tt.sig)
+			if got.Name != tt.want.Name || got.Email != tt.want.Email {
+				t.Errorf("Resolve(%s <%s>) = %s <%s>, want %s <%s>", tt.sig.Name, tt.sig.Email, got.Name, got.Email, tt.want.Name, tt.want.Email)
+			}
+		})
+	}
+}
+
+func TestCommitAuthors(t *testing.T) {
+	m, err := ParseMailmap(strings.NewReader(testMailmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &object.Commit{
+		Author: object.Signature{Name: "jdoe", Email: "jane@webpoint.io"},
+		Message: "feat: pair on login\n\n" +
+			"Co-authored-by: johnny <john@users.noreply.github.com>\n" +
+			"co-authored-by: Jane <jane.doe@old-laptop.local>\n" +
+			"Co-Authored-By: Dev <dev@example.com>\n",
+	}
+
+	var got []string
+	for _, sig := range commitAuthors(c, m) {
+		got = append(got, sig.Name+" <"+sig.Email+">")
+	}
+	want := []string{"Jane Doe <jane@webpoint.io>", "John Smith <john@webpoint.io>", "Dev <dev@example.com>"}
+	if !slices.Equal(got, want) {
+		t.Errorf("commitAuthors() = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateMailmap(t *testing.T) {
+	r := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(r.dir, ".mailmap"), []byte(testMailmap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.commit("jdoe <jane.doe@old-laptop.local>", "feat: login form\n\nCo-authored-by: johnny <john@users.noreply.github.com>")
+
+	tests := map[string]struct {
+		authors []string
+		want    []string
+	}{
+		"every author":    {want: []string{"John Smith", "jdoe"}},
+		"canonical email": {authors: []string{"jane@webpoint.io"}, want: []string{"jdoe"}},
+		"canonical name":  {authors: []string{"john smith"}, want: []string{"John Smith"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, rec := range r.generate(t, Options{Authors: tt.authors}).Records {
+				got = append(got, rec.Author)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("authors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateDefaultAuthor(t *testing.T) {
+	r := newTestRepo(t)
+	mailmap := "An <an@webpoint.io> <an@old-laptop.local>\n"
+	if err := os.WriteFile(filepath.Join(r.dir, ".mailmap"), []byte(mailmap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.commit("An <an@webpoint.io>", "feat: login form")
+	r.commit("An <an@old-laptop.local>", "fix: login typo")
+	r.commit("Dan <dan@webpoint.io>", "feat: signup form")
+	r.commit("Joan <joan@webpoint.io>", "feat: logout")
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Email = "AN@old-laptop.local"
+	if err := r.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		authors []string
+		want    []string
+	}{
+		"git user.email":    {want: []string{"login form", "login typo"}},
+		"explicit patterns": {authors: []string{"an@webpoint.io"}, want: []string{"login form", "login typo", "logout", "signup form"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rep, err := Generate(context.Background(), Options{Folders: []string{r.dir}, Authors: tt.authors})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, rec := range rep.Records {
+				got = append(got, rec.Description)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commits = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorSlug(t *testing.T) {
+	tests := map[string]string{
+		"john.doe@webpoint.io":  "john.doe",
+		"Jane Doe+git@host":     "Jane-Doe-git",
+		"@webpoint.io":          "unknown",
+		"..@webpoint.io":        "unknown",
+		"dependabot[bot]@users": "dependabot-bot",
+	}
+	for email, want := range tests {
+		if got := AuthorSlug(email); got != want {
+			t.Errorf("AuthorSlug(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package report
+
+import "time"
+
+// SheetMeta is stored next to every uploaded sheet. The CLI sends what it
+// knows about the run, the server fills in the rest on upload.
+type SheetMeta struct {
+	ID          string     `json:"id"`
+	FileName    string     `json:"file_name"`
+	Repo        string     `json:"repo"`
+	Branch      string     `json:"branch"`           // branch filter, empty = all branches
+	SinceDays   int        `json:"since_days"`       // -t window, 0 = all history
+	Since       *time.Time `json:"since,omitempty"`  // first instant of the commit range
+	Until       *time.Time `json:"until,omitempty"`  // end of the commit range, exclusive
+	Period      string     `json:"period,omitempty"` // eg: last-month
+	GeneratedBy string     `json:"generated_by"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+	UploadedBy  string     `json:"uploaded_by"`
+	TokenID     string     `json:"token_id,omitempty"` // API token the sheet was uploaded with
+	Receivers   []string   `json:"receivers"`
+	Description string     `json:"description"`
+
+	Status  string         `json:"status"`
+	Reason  string         `json:"reason,omitempty"` // why the sheet was last rejected
+	History []StatusChange `json:"history"`
+}
+
+// a sheet moves draft -> submitted -> approved or rejected, a rejected sheet
+// can be resubmitted and reviewed again
+const (
+	SheetStatusDraft       = "draft"
+	SheetStatusSubmitted   = "submitted"
+	SheetStatusApproved    = "approved"
+	SheetStatusRejected    = "rejected"
+	SheetStatusResubmitted = "resubmitted"
+)
+
+type StatusChange struct {
+	Status string    `json:"status"`
+	By     string    `json:"by"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// SheetJSON is a sheet in JSON, as written by `--format json` and served by
+// GET /csv/:id?format=json
+type SheetJSON struct {
+	Meta       *SheetMeta `json:"meta,omitempty"`
+	Header     []string   `json:"header"`
+	Rows       [][]string `json:"rows"`
+	TotalHours float64    `json:"total_hours"`
+}
@@ -0,0 +1,224 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// how merge commits are handled, see Options.Merges
+const (
+	MergesKeep     = "keep"     // a row like any other commit
+	MergesDrop     = "drop"     // no row
+	MergesCollapse = "collapse" // no row, the merged commits take over its tickets
+)
+
+// how reverts are handled, see Options.Reverts
+const (
+	RevertsKeep = "keep" // a row like any other commit
+	RevertsPair = "pair" // both rows name each other in their description
+	RevertsDrop = "drop" // a revert and the commit it reverts cancel out, both are dropped
+)
+
+var (
+	MergeModes  = []string{MergesKeep, MergesDrop, MergesCollapse}
+	RevertModes = []string{RevertsKeep, RevertsPair, RevertsDrop}
+)
+
+// DroppedColumn is added to the sheet when Options.ShowDropped is set
+const DroppedColumn = "Dropped"
+
+// BotAuthors are dropped with Options.ExcludeBots, matched like ExcludeAuthors
+var BotAuthors = []string{"[bot]", "dependabot", "renovate", "greenkeeper", "snyk-bot", "github-actions"}
+
+// eg: This reverts commit 0f96a7349d3f1fbb6ef4de1a8bc2c7e6c0e3a1c2.
+var revertRegex = regexp.MustCompile(`This reverts commit ([0-9a-f]{40})`)
+
+// eg: Merge pull request #12 from org/PROJ-7-login, Merge branch 'PROJ-7' into main
+var mergeSourceRegex = regexp.MustCompile(`^Merge (?:pull request (#\d+) from (\S+)|(?:remote-tracking )?branch '([^']+)')`)
+
+// noiseRules decide which commits are noise rather than work
+type noiseRules struct {
+	merges   string
+	reverts  string
+	messages []*regexp.Regexp
+}
+
+// noise is what the rules found in one repository's commits
+type noise struct {
+	dropped map[plumbing.Hash]string   // why the commit was dropped
+	notes   map[plumbing.Hash]string   // appended to the description
+	tickets map[plumbing.Hash][]string // inherited from a collapsed merge
+}
+
+func newNoiseRules(opts Options) (*noiseRules, error) {
+	rules := &noiseRules{merges: opts.Merges, reverts: opts.Reverts}
+	if rules.merges == "" {
+		rules.merges = MergesKeep
+	}
+	if rules.reverts == "" {
+		rules.reverts = RevertsKeep
+	}
+	if !slices.Contains(MergeModes, rules.merges) {
+		return nil, fmt.Errorf("%w: unknown merge mode %q, expected one of: %s", ErrInvalidOption, opts.Merges, strings.Join(MergeModes, ", "))
+	}
+	if !slices.Contains(RevertModes, rules.reverts) {
+		return nil, fmt.Errorf("%w: unknown revert mode %q, expected one of: %s", ErrInvalidOption, opts.Reverts, strings.Join(RevertModes, ", "))
+	}
+
+	for _, pattern := range opts.ExcludeMessages {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: message pattern %q: %w", ErrInvalidOption, pattern, err)
+		}
+		rules.messages = append(rules.messages, re)
+	}
+
+	return rules, nil
+}
+
+// apply runs the rules over `commits`, the sheet candidates of one repository
+func (r *noiseRules) apply(ctx context.Context, commits []*object.Commit, tickets *TicketMatcher) (*noise, error) {
+	n := &noise{
+		dropped: make(map[plumbing.Hash]string),
+		notes:   make(map[plumbing.Hash]string),
+		tickets: make(map[plumbing.Hash][]string),
+	}
+
+	byHash := make(map[plumbing.Hash]*object.Commit, len(commits))
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+
+	for _, c := range commits {
+		reason := r.reason(c)
+		if reason == "" {
+			continue
+		}
+		n.dropped[c.Hash] = reason
+		if reason == "merge" && r.merges == MergesCollapse {
+			if err := n.collapse(ctx, c, byHash, tickets); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.reverts != RevertsKeep {
+		for _, c := range commits {
+			original, ok := revertOf(c)
+			if !ok || byHash[original] == nil {
+				continue // the reverted commit isn't in the sheet
+			}
+
+			if r.reverts == RevertsDrop {
+				setOnce(n.dropped, original, "reverted by "+shortHash(c.Hash))
+				setOnce(n.dropped, c.Hash, "reverts "+shortHash(original))
+			} else {
+				setOnce(n.notes, original, "reverted by "+shortHash(c.Hash))
+				setOnce(n.notes, c.Hash, "reverts "+shortHash(original))
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// reason returns why `c` is dropped as a merge or for its message, or ""
+func (r *noiseRules) reason(c *object.Commit) string {
+	if c.NumParents() > 1 && r.merges != MergesKeep {
+		return "merge"
+	}
+	if slices.ContainsFunc(r.messages, func(re *regexp.Regexp) bool { return re.MatchString(c.Message) }) {
+		return "excluded message"
+	}
+	return ""
+}
+
+// revertOf returns the commit `c` reverts, from the message git revert writes
+func revertOf(c *object.Commit) (plumbing.Hash, bool) {
+	match := revertRegex.FindStringSubmatch(c.Message)
+	if match == nil {
+		return plumbing.ZeroHash, false
+	}
+	return plumbing.NewHash(match[1]), true
+}
+
+// collapse hands the tickets named in the source of `merge` to the commits it
+// brought in, those reachable from its other parents but not its first one
+func (n *noise) collapse(ctx context.Context, merge *object.Commit, sheet map[plumbing.Hash]*object.Commit, tickets *TicketMatcher) error {
+	keys := tickets.Find(mergeSource(merge.Message))
+	if len(keys) == 0 {
+		return nil
+	}
+
+	first, err := merge.Parent(0)
+	if err != nil {
+		return ignoreMissing(err)
+	}
+
+	for i := 1; i < merge.NumParents(); i++ {
+		parent, err := merge.Parent(i)
+		if err != nil {
+			return ignoreMissing(err)
+		}
+		bases, err := first.MergeBase(parent)
+		if err != nil {
+			return ignoreMissing(err)
+		}
+
+		var stop []plumbing.Hash
+		for _, base := range bases {
+			stop = append(stop, base.Hash)
+		}
+
+		iter := object.NewCommitPreorderIter(parent, nil, stop)
+		err = iter.ForEach(func(c *object.Commit) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if sheet[c.Hash] != nil {
+				for _, key := range keys {
+					if !slices.Contains(n.tickets[c.Hash], key) {
+						n.tickets[c.Hash] = append(n.tickets[c.Hash], key)
+					}
+				}
+			}
+			return nil
+		})
+		iter.Close()
+		if err := ignoreMissing(err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeSource returns the merged branch or pull request named in a merge
+// message, or its subject when the message isn't one git or a forge writes
+func mergeSource(message string) string {
+	subject := commitSubject(message)
+	match := mergeSourceRegex.FindStringSubmatch(subject)
+	if match == nil {
+		return subject
+	}
+	if match[1] != "" {
+		return match[1] + " " + match[2]
+	}
+	return match[3]
+}
+
+func setOnce(m map[plumbing.Hash]string, hash plumbing.Hash, value string) {
+	if _, ok := m[hash]; !ok {
+		m[hash] = value
+	}
+}
+
+func shortHash(hash plumbing.Hash) string {
+	return hash.String()[:7]
+}
@@ -0,0 +1,165 @@
+package report
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// byHash indexes the records of `rep` by commit hash
+func byHash(rep *Report) map[plumbing.Hash]Record {
+	records := make(map[plumbing.Hash]Record)
+	for _, rec := range rep.Records {
+		records[plumbing.NewHash(rec.Hash)] = rec
+	}
+	return records
+}
+
+func TestGenerateMerges(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("Dev <dev@example.com>", "feat: base work")
+	r.checkout("PROJ-9-export", true)
+	export := r.commit("Dev <dev@example.com>", "feat: export button")
+	r.checkout("main", false)
+	merge := r.merge("PROJ-9-export", "Merge pull request #12 from org/PROJ-9-export")
+	r.deleteBranch("PROJ-9-export")
+
+	tests := map[string]struct {
+		merges  string
+		dropped string   // of the merge
+		tickets []string // of the merged commit
+	}{
+		"keep":     {merges: MergesKeep},
+		"drop":     {merges: MergesDrop, dropped: "merge"},
+		"collapse": {merges: MergesCollapse, dropped: "merge", tickets: []string{"PROJ-9", "#12"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			records := byHash(r.generate(t, Options{Merges: tt.merges, ShowDropped: true}))
+			if len(records) != 3 {
+				t.Fatalf("got %d records, want 3", len(records))
+			}
+			if got := records[merge].Dropped; got != tt.dropped {
+				t.Errorf("merge dropped = %q, want %q", got, tt.dropped)
+			}
+			if got := records[export].Tickets; !slices.Equal(got, tt.tickets) {
+				t.Errorf("merged commit tickets = %v, want %v", got, tt.tickets)
+			}
+			if got := records[base].Tickets; got != nil {
+				t.Errorf("base commit tickets = %v, want none", got)
+			}
+		})
+	}
+
+	// dropped rows are left out of the sheet unless asked for
+	records := byHash(r.generate(t, Options{Merges: MergesDrop}))
+	if _, ok := records[merge]; ok || len(records) != 2 {
+		t.Errorf("got %d records with the merge %v, want 2 without it", len(records), ok)
+	}
+}
+
+func TestGenerateReverts(t *testing.T) {
+	r := newTestRepo(t)
+	original := r.commit("Dev <dev@example.com>", "feat: risky change")
+	r.commit("Dev <dev@example.com>", "fix: unrelated")
+	revert := r.commit("Dev <dev@example.com>", "Revert \"feat: risky change\"\n\nThis reverts commit "+original.String()+".")
+
+	tests := map[string]struct {
+		reverts                  string
+		originalDesc, revertDesc string
+		dropped                  bool
+	}{
+		"keep": {
+			reverts:      RevertsKeep,
+			originalDesc: "risky change",
+			revertDesc:   `Revert "feat: risky change"`,
+		},
+		"pair": {
+			reverts:      RevertsPair,
+			originalDesc: "risky change (reverted by " + shortHash(revert) + ")",
+			revertDesc:   `Revert "feat: risky change" (reverts ` + shortHash(original) + ")",
+		},
+		"drop": {
+			reverts:      RevertsDrop,
+			originalDesc: "risky change",
+			revertDesc:   `Revert "feat: risky change"`,
+			dropped:      true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			records := byHash(r.generate(t, Options{Reverts: tt.reverts, ShowDropped: true}))
+
+			if got := records[original].Description; got != tt.originalDesc {
+				t.Errorf("original description = %q, want %q", got, tt.originalDesc)
+			}
+			if got := records[revert].Description; got != tt.revertDesc {
+				t.Errorf("revert description = %q, want %q", got, tt.revertDesc)
+			}
+
+			want := map[plumbing.Hash]string{}
+			if tt.dropped {
+				want[original] = "reverted by " + shortHash(revert)
+				want[revert] = "reverts " + shortHash(original)
+			}
+			for hash, rec := range records {
+				if rec.Dropped != want[hash] {
+					t.Errorf("%s dropped = %q, want %q", shortHash(hash), rec.Dropped, want[hash])
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateExcludedCommits(t *testing.T) {
+	r := newTestRepo(t)
+	work := r.commit("Dev <dev@example.com>", "feat: login form")
+	wip := r.commit("Dev <dev@example.com>", "WIP: try something")
+	bot := r.commit("dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>", "chore(deps): bump x")
+
+	records := byHash(r.generate(t, Options{ExcludeMessages: []string{`^WIP`}, ExcludeBots: true, ShowDropped: true}))
+
+	want := map[plumbing.Hash]string{work: "", wip: "excluded message", bot: "excluded author"}
+	for hash, dropped := range want {
+		if got := records[hash].Dropped; got != dropped {
+			t.Errorf("%s dropped = %q, want %q", shortHash(hash), got, dropped)
+		}
+	}
+	if got := records[wip].Estimate; got != (Estimate{}) {
+		t.Errorf("excluded commit got hours: %+v", got)
+	}
+}
+
+func TestNoiseRulesInvalid(t *testing.T) {
+	tests := map[string]Options{
+		"merge mode":      {Merges: "squash"},
+		"revert mode":     {Reverts: "undo"},
+		"message pattern": {ExcludeMessages: []string{`(wip`}},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newNoiseRules(opts); !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("got %v, want ErrInvalidOption", err)
+			}
+		})
+	}
+}
+
+func TestMergeSource(t *testing.T) {
+	tests := map[string]string{
+		"Merge pull request #12 from org/PROJ-7-login\n\nLogin form": "#12 org/PROJ-7-login",
+		"Merge branch 'PROJ-7' into main":                            "PROJ-7",
+		"Merge remote-tracking branch 'origin/PROJ-8'":               "origin/PROJ-8",
+		"PROJ-9: squash of the export (#14)":                         "PROJ-9: squash of the export (#14)",
+	}
+	for message, want := range tests {
+		if got := mergeSource(message); got != want {
+			t.Errorf("mergeSource(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
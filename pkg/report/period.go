@@ -0,0 +1,171 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Periods lists the names ResolvePeriod accepts
+var Periods = []string{"today", "yesterday", "this-week", "last-week", "this-month", "last-month"}
+
+// RangeColumn is added to the sheet when its range is bounded, so a CSV
+// states the range it covers like the titles of the other formats
+const RangeColumn = "Range"
+
+// DateRange selects commits authored in [Since, Until), a zero bound is open
+type DateRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// MetaRange returns the commit range stored with a sheet
+func MetaRange(meta SheetMeta) DateRange {
+	var r DateRange
+	if meta.Since != nil {
+		r.Since = *meta.Since
+	}
+	if meta.Until != nil {
+		r.Until = *meta.Until
+	}
+	return r
+}
+
+// IsZero reports whether the range covers all history
+func (r DateRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+func (r DateRange) Contains(t time.Time) bool {
+	return (r.Since.IsZero() || !t.Before(r.Since)) && (r.Until.IsZero() || t.Before(r.Until))
+}
+
+// String formats the range with inclusive dates, eg: "2024-03-01 to 2024-03-31"
+func (r DateRange) String() string {
+	since, until := "the beginning", "now"
+	if !r.Since.IsZero() {
+		since = r.Since.Format(time.DateOnly)
+	}
+	if !r.Until.IsZero() {
+		until = r.Until.Add(-time.Nanosecond).Format(time.DateOnly)
+	}
+	return since + " to " + until
+}
+
+// RangeQuery is a commit range as people ask for it, the CLI's -t, --since,
+// --until and --period or the same fields of a server request
+type RangeQuery struct {
+	SinceDays int    // only checked against the other fields, see Options.SinceDays
+	Since     string // see ParseRangeDate
+	Until     string
+	Period    string // one of Periods
+	WeekStart string // first day of the week for Period (empty = monday)
+	Timezone  string // location dates and periods are read in (empty = Local)
+}
+
+// Resolve returns the range the query selects at `now`
+func (q RangeQuery) Resolve(now time.Time) (DateRange, error) {
+	var r DateRange
+
+	loc := time.Local
+	if q.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(q.Timezone); err != nil {
+			return r, fmt.Errorf("invalid timezone %q: %w", q.Timezone, err)
+		}
+	}
+
+	if q.SinceDays != 0 && (q.Since != "" || q.Period != "") {
+		return r, fmt.Errorf("a number of days back can't be combined with a since date or a period")
+	}
+
+	if q.Period != "" {
+		if q.Since != "" || q.Until != "" {
+			return r, fmt.Errorf("a period can't be combined with since or until dates")
+		}
+
+		weekStart := time.Monday
+		if q.WeekStart != "" {
+			var err error
+			if weekStart, err = ParseWeekday(q.WeekStart); err != nil {
+				return r, err
+			}
+		}
+		return ResolvePeriod(q.Period, now, weekStart, loc)
+	}
+
+	var err error
+	if q.Since != "" {
+		if r.Since, err = ParseRangeDate(q.Since, loc, false); err != nil {
+			return r, err
+		}
+	}
+	if q.Until != "" {
+		if r.Until, err = ParseRangeDate(q.Until, loc, true); err != nil {
+			return r, err
+		}
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Since.Before(r.Until) {
+		return r, fmt.Errorf("since must be before until")
+	}
+
+	return r, nil
+}
+
+// ResolvePeriod turns a period name into a range of whole days in `loc`,
+// weeks start on `weekStart`
+func ResolvePeriod(name string, now time.Time, weekStart time.Weekday, loc *time.Location) (DateRange, error) {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	week := today.AddDate(0, 0, -((int(today.Weekday()) - int(weekStart) + 7) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+
+	switch name {
+	case "today":
+		return DateRange{Since: today, Until: today.AddDate(0, 0, 1)}, nil
+	case "yesterday":
+		return DateRange{Since: today.AddDate(0, 0, -1), Until: today}, nil
+	case "this-week":
+		return DateRange{Since: week, Until: week.AddDate(0, 0, 7)}, nil
+	case "last-week":
+		return DateRange{Since: week.AddDate(0, 0, -7), Until: week}, nil
+	case "this-month":
+		return DateRange{Since: month, Until: month.AddDate(0, 1, 0)}, nil
+	case "last-month":
+		return DateRange{Since: month.AddDate(0, -1, 0), Until: month}, nil
+	default:
+		return DateRange{}, fmt.Errorf("unknown period %q, expected one of: %s", name, strings.Join(Periods, ", "))
+	}
+}
+
+// ParseRangeDate parses a --since/--until value in `loc`, eg: "2024-03-01",
+// "2024-03-01 14:00" or RFC 3339. A plain date given as the end of a range
+// includes that whole day.
+func ParseRangeDate(value string, loc *time.Location, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04", time.DateTime, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+}
+
+// ParseWeekday parses a day name, eg: "monday" or "Sun"
+func ParseWeekday(value string) (time.Weekday, error) {
+	value = strings.ToLower(value)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if value == name || (len(value) >= 3 && strings.HasPrefix(name, value)) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", value)
+}
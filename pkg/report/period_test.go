@@ -0,0 +1,140 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvePeriod(t *testing.T) {
+	loc := time.FixedZone("NPT", 5*60*60+45*60)
+	// a Wednesday, late enough that it is already Thursday in UTC+5:45
+	now := time.Date(2025, 4, 16, 20, 0, 0, 0, time.UTC)
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, loc) }
+
+	tests := map[string]struct {
+		period    string
+		weekStart time.Weekday
+		want      DateRange
+	}{
+		"today":                 {period: "today", want: DateRange{day(4, 17), day(4, 18)}},
+		"yesterday":             {period: "yesterday", want: DateRange{day(4, 16), day(4, 17)}},
+		"this week":             {period: "this-week", weekStart: time.Monday, want: DateRange{day(4, 14), day(4, 21)}},
+		"last week":             {period: "last-week", weekStart: time.Monday, want: DateRange{day(4, 7), day(4, 14)}},
+		"this week from sunday": {period: "this-week", weekStart: time.Sunday, want: DateRange{day(4, 13), day(4, 20)}},
+		"week starting today":   {period: "this-week", weekStart: time.Thursday, want: DateRange{day(4, 17), day(4, 24)}},
+		"this month":            {period: "this-month", want: DateRange{day(4, 1), day(5, 1)}},
+		"last month":            {period: "last-month", want: DateRange{day(3, 1), day(4, 1)}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolvePeriod(tt.period, now, tt.weekStart, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Since.Equal(tt.want.Since) || !got.Until.Equal(tt.want.Until) {
+				t.Errorf("ResolvePeriod(%q) = %s, want %s", tt.period, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ResolvePeriod("fortnight", now, time.Monday, loc); err == nil {
+		t.Error("got no error for an unknown period")
+	}
+}
+
+func TestParseRangeDate(t *testing.T) {
+	loc := time.FixedZone("NPT", 5*60*60+45*60)
+
+	tests := map[string]struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		"date":                {value: "2025-03-01", want: time.Date(2025, 3, 1, 0, 0, 0, 0, loc)},
+		"date ending range":   {value: "2025-03-31", end: true, want: time.Date(2025, 4, 1, 0, 0, 0, 0, loc)},
+		"minutes":             {value: "2025-03-01 14:30", want: time.Date(2025, 3, 1, 14, 30, 0, 0, loc)},
+		"seconds":             {value: "2025-03-01 14:30:15", end: true, want: time.Date(2025, 3, 1, 14, 30, 15, 0, loc)},
+		"rfc 3339 keeps zone": {value: "2025-03-01T14:30:00Z", want: time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseRangeDate(tt.value, loc, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseRangeDate(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "01/03/2025", "2025-13-01", "last week"} {
+		if _, err := ParseRangeDate(value, loc, false); err == nil {
+			t.Errorf("ParseRangeDate(%q): got no error", value)
+		}
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := map[string]time.Weekday{
+		"monday": time.Monday,
+		"Sunday": time.Sunday,
+		"SAT":    time.Saturday,
+		"thurs":  time.Thursday,
+	}
+	for value, want := range tests {
+		if got, err := ParseWeekday(value); err != nil || got != want {
+			t.Errorf("ParseWeekday(%q) = %s, %v, want %s", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "mo", "funday"} {
+		if _, err := ParseWeekday(value); err == nil {
+			t.Errorf("ParseWeekday(%q): got no error", value)
+		}
+	}
+}
+
+func TestRangeQueryResolve(t *testing.T) {
+	now := time.Date(2025, 4, 16, 12, 0, 0, 0, time.UTC) // a Wednesday
+
+	tests := map[string]struct {
+		query RangeQuery
+		want  string // DateRange.String, or the error
+	}{
+		"empty":            {query: RangeQuery{}, want: "the beginning to now"},
+		"days back":        {query: RangeQuery{SinceDays: 7}, want: "the beginning to now"},
+		"days back, until": {query: RangeQuery{SinceDays: 7, Until: "2025-04-10"}, want: "the beginning to 2025-04-10"},
+		"dates":            {query: RangeQuery{Since: "2025-03-01", Until: "2025-03-31", Timezone: "UTC"}, want: "2025-03-01 to 2025-03-31"},
+		"period":           {query: RangeQuery{Period: "last-week", Timezone: "UTC"}, want: "2025-04-07 to 2025-04-13"},
+		"week start":       {query: RangeQuery{Period: "last-week", WeekStart: "sunday", Timezone: "UTC"}, want: "2025-04-06 to 2025-04-12"},
+		"timezone":         {query: RangeQuery{Period: "today", Timezone: "Pacific/Kiritimati"}, want: "2025-04-17 to 2025-04-17"},
+
+		"days back and since":  {query: RangeQuery{SinceDays: 7, Since: "2025-03-01"}, want: "days back"},
+		"days back and period": {query: RangeQuery{SinceDays: 7, Period: "today"}, want: "days back"},
+		"period and dates":     {query: RangeQuery{Period: "today", Until: "2025-03-01"}, want: "period"},
+		"reversed dates":       {query: RangeQuery{Since: "2025-03-31", Until: "2025-03-01"}, want: "before"},
+		"unknown timezone":     {query: RangeQuery{Timezone: "Mars/Olympus"}, want: "timezone"},
+		"unknown week start":   {query: RangeQuery{Period: "this-week", WeekStart: "funday"}, want: "weekday"},
+		"invalid date":         {query: RangeQuery{Since: "March"}, want: "invalid date"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.query.Resolve(now)
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("got error %q, want %q", err, tt.want)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
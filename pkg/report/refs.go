@@ -0,0 +1,148 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ref globs walked by default and with Options.RemoteBranches and Options.Tags
+const (
+	LocalBranchRefs  = "refs/heads/*"
+	RemoteBranchRefs = "refs/remotes/*"
+	TagRefs          = "refs/tags/*"
+)
+
+// refFilter picks the refs whose history goes into the sheet. Patterns are
+// matched against the full ref name, or the short one when they don't start
+// with refs/; `*` matches any run of characters including `/` and a pattern
+// without wildcards matches the ref and everything below it, like git log --glob.
+type refFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newRefFilter(opts Options) (*refFilter, error) {
+	include := []string{LocalBranchRefs}
+	if opts.RemoteBranches {
+		include = append(include, RemoteBranchRefs)
+	}
+	if opts.Tags {
+		include = append(include, TagRefs)
+	}
+	include = append(include, opts.Refs...)
+
+	f := &refFilter{}
+	for _, pattern := range include {
+		re, err := refPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range opts.ExcludeRefs {
+		re, err := refPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+func refPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("%w: empty ref pattern", ErrInvalidOption)
+	}
+
+	expr := regexp.QuoteMeta(strings.TrimSuffix(pattern, "/"))
+	expr = strings.NewReplacer(`\*`, `.*`, `\?`, `.`).Replace(expr)
+	if !strings.ContainsAny(pattern, "*?") {
+		expr += `(/.*)?`
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+func (f *refFilter) matches(name plumbing.ReferenceName) bool {
+	match := func(patterns []*regexp.Regexp) bool {
+		return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(name.String()) || re.MatchString(name.Short())
+		})
+	}
+	return match(f.include) && !match(f.exclude)
+}
+
+// selectRefs returns the refs of `repo` the filter picks, by name. Symbolic
+// refs such as refs/remotes/origin/HEAD are left out, their target is walked
+// on its own.
+func (f *refFilter) selectRefs(repo *git.Repository) ([]*plumbing.Reference, error) {
+	iter, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []*plumbing.Reference
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference && f.matches(ref.Name()) {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(refs, func(a, b *plumbing.Reference) int { return strings.Compare(a.Name().String(), b.Name().String()) })
+	return refs, nil
+}
+
+// branchRefs returns the local and remote-tracking branches of `refs`
+func branchRefs(refs []*plumbing.Reference) []*plumbing.Reference {
+	var branches []*plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name().IsBranch() || ref.Name().IsRemote() {
+			branches = append(branches, ref)
+		}
+	}
+	return branches
+}
+
+// findRef resolves the -b branch, a local branch or else a remote-tracking
+// one, eg: origin/feature or feature on origin
+func findRef(repo *git.Repository, branch string) (*plumbing.Reference, error) {
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return ref, err
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.ReferenceName("refs/remotes/" + branch),
+		plumbing.NewRemoteReferenceName("origin", branch),
+	} {
+		if remote, err := repo.Reference(name, true); err == nil {
+			return remote, nil
+		}
+	}
+	return nil, err
+}
+
+// peelCommit returns the commit `ref` points at, through an annotated tag.
+// Tags of trees or blobs return the zero hash.
+func peelCommit(repo *git.Repository, ref *plumbing.Reference) (plumbing.Hash, error) {
+	tag, err := repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return ref.Hash(), nil // not an annotated tag
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if tag.TargetType != plumbing.CommitObject {
+		return plumbing.ZeroHash, nil
+	}
+	return tag.Target, nil
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRefFilter(t *testing.T) {
+	refs := []plumbing.ReferenceName{
+		"refs/heads/main",
+		"refs/heads/feature/login",
+		"refs/heads/feature/logout",
+		"refs/heads/release/1.0",
+		"refs/remotes/origin/main",
+		"refs/remotes/origin/feature/login",
+		"refs/tags/v1.0.0",
+		"refs/tags/v2.0.0-rc1",
+	}
+
+	tests := map[string]struct {
+		opts Options
+		want []string // short names
+	}{
+		"local branches by default": {
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0"},
+		},
+		"remote branches": {
+			opts: Options{RemoteBranches: true},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "origin/main", "origin/feature/login"},
+		},
+		"tags": {
+			opts: Options{Tags: true},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "v1.0.0", "v2.0.0-rc1"},
+		},
+		"exclude a subtree": {
+			opts: Options{ExcludeRefs: []string{"feature"}},
+			want: []string{"main", "release/1.0"},
+		},
+		"exclude by full name": {
+			opts: Options{ExcludeRefs: []string{"refs/heads/release/*"}},
+			want: []string{"main", "feature/login", "feature/logout"},
+		},
+		"star crosses slashes": {
+			opts: Options{ExcludeRefs: []string{"*login"}},
+			want: []string{"main", "feature/logout", "release/1.0"},
+		},
+		"question mark": {
+			opts: Options{Tags: true, ExcludeRefs: []string{"v?.0.0"}},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "v2.0.0-rc1"},
+		},
+		"include a tag glob": {
+			opts: Options{Refs: []string{"refs/tags/v1.*"}},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "v1.0.0"},
+		},
+		"include a remote branch": {
+			opts: Options{Refs: []string{"origin/feature"}},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "origin/feature/login"},
+		},
+		"exclude wins over include": {
+			opts: Options{Tags: true, Refs: []string{"origin/*"}, ExcludeRefs: []string{"*rc*", "origin/main"}},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0", "origin/feature/login", "v1.0.0"},
+		},
+		"exclude the default branches": {
+			opts: Options{Tags: true, ExcludeRefs: []string{"refs/heads/*"}},
+			want: []string{"v1.0.0", "v2.0.0-rc1"},
+		},
+		"no partial names": {
+			opts: Options{ExcludeRefs: []string{"feat", "mai"}},
+			want: []string{"main", "feature/login", "feature/logout", "release/1.0"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := newRefFilter(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, ref := range refs {
+				if f.matches(ref) {
+					got = append(got, ref.Short())
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefFilterEmptyPattern(t *testing.T) {
+	for _, opts := range []Options{{Refs: []string{" "}}, {ExcludeRefs: []string{""}}} {
+		if _, err := newRefFilter(opts); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%+v: got %v, want ErrInvalidOption", opts, err)
+		}
+	}
+}
+
+func TestGenerateTags(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work")
+	r.checkout("release", true)
+	released := r.commit("Dev <dev@example.com>", "fix: release fix")
+	r.checkout("main", false)
+
+	// the release branch is gone, its tags are left
+	if _, err := r.repo.CreateTag("v1.0.0", released, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "Dev", Email: "dev@example.com", When: r.clock},
+		Message: "v1.0.0",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.repo.CreateTag("v1.0.0-light", released, nil); err != nil {
+		t.Fatal(err)
+	}
+	r.deleteBranch("release")
+
+	tests := map[string]struct {
+		opts Options
+		want []string
+	}{
+		"branches only":  {want: []string{"base work"}},
+		"tags":           {opts: Options{Tags: true}, want: []string{"base work", "release fix"}},
+		"annotated only": {opts: Options{Tags: true, ExcludeRefs: []string{"*-light"}}, want: []string{"base work", "release fix"}},
+		"tags excluded":  {opts: Options{Tags: true, ExcludeRefs: []string{"refs/tags/*"}}, want: []string{"base work"}},
+		"tag as a ref":   {opts: Options{Refs: []string{"v1.0.0-light"}}, want: []string{"base work", "release fix"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := []string{}
+			for description := range byDescription(r.generate(t, tt.opts)) {
+				got = append(got, description)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// RemoteAuth holds the credentials remote repositories are cloned with
+type RemoteAuth struct {
+	Username string // https user, defaults to "git" when only a token is set
+	Password string // https password or access token
+
+	SSHKeyPath       string // private key, empty = ssh-agent
+	SSHKeyPassphrase string
+}
+
+// IsRemote reports whether `location` is a repository URL rather than a local path
+func IsRemote(location string) bool {
+	if strings.Contains(location, "://") {
+		return true
+	}
+	// scp-like ssh, eg: git@github.com:org/repo.git
+	user, rest, ok := strings.Cut(location, "@")
+	return ok && !strings.ContainsAny(user, "/\\") && strings.Contains(rest, ":")
+}
+
+// RemoteProtocol returns the transport `location` is cloned over, eg: https,
+// ssh or file
+func RemoteProtocol(location string) (string, error) {
+	endpoint, err := transport.NewEndpoint(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %w", err)
+	}
+	return endpoint.Protocol, nil
+}
+
+// method returns the transport auth for `endpoint`, nil when none is needed
+func (a RemoteAuth) method(endpoint *transport.Endpoint) (transport.AuthMethod, error) {
+	switch endpoint.Protocol {
+	case "ssh":
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		if a.SSHKeyPath == "" {
+			return ssh.NewSSHAgentAuth(user)
+		}
+		return ssh.NewPublicKeysFromFile(user, expandHome(a.SSHKeyPath), a.SSHKeyPassphrase)
+
+	case "http", "https":
+		if a.Password == "" {
+			return nil, nil
+		}
+		username := a.Username
+		if username == "" {
+			username = "git" // any non-empty name works with access tokens
+		}
+		return &http.BasicAuth{Username: username, Password: a.Password}, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// cloneRemote mirrors the repository at `location` into memory. Only commit
+// metadata is read, so `depth` (0 = full history) can keep the clone small.
+func cloneRemote(ctx context.Context, location string, auth RemoteAuth, depth int) (namedRepo, error) {
+	endpoint, err := transport.NewEndpoint(location)
+	if err != nil {
+		return namedRepo{}, fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	method, err := auth.method(endpoint)
+	if err != nil {
+		return namedRepo{}, fmt.Errorf("could not load credentials: %w", err)
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+		URL:    location,
+		Auth:   method,
+		Mirror: true, // refs/heads/*, tags and notes as they are on the remote
+		Depth:  depth,
+	})
+	if err != nil {
+		return namedRepo{}, fmt.Errorf("failed to clone %s: %w", redactURL(location), err)
+	}
+
+	return namedRepo{name: remoteName(endpoint), repo: repo}, nil
+}
+
+// remoteName returns the repository name of a URL, eg: sheet-happens for
+// git@github.com:org/sheet-happens.git
+func remoteName(endpoint *transport.Endpoint) string {
+	return strings.TrimSuffix(path.Base(strings.TrimSuffix(endpoint.Path, "/")), ".git")
+}
+
+// redactURL hides the password of `location` in error messages
+func redactURL(location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.User == nil {
+		return location
+	}
+	return u.Redacted()
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
@@ -0,0 +1,206 @@
+package report
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Columns is the header of a generated sheet, the first six are the ones the
+// server requires. TimeStamp holds the duration credited to the commit.
+var Columns = []string{
+	"Date", "Author Name", "Commit Type", "Scope", "Description", "TimeStamp",
+	"Repository", TicketColumn, "Hours", "Source", "Session", "Session Hours",
+}
+
+// Report is the result of Generate
+type Report struct {
+	Repo        string   // names of Repos, separated by ", "
+	Repos       []string // every repository the report covers
+	Branch      string   // empty = all branches
+	SinceDays   int
+	Range       DateRange
+	Period      string
+	Description string
+	GeneratedBy string
+	GeneratedAt time.Time
+
+	// optional columns
+	HashColumn      bool // Rows adds the HashColumn
+	ShortHashColumn bool // Rows adds the ShortHashColumn
+	BranchColumn    bool // Rows adds the BranchColumn
+	DiffStats       bool // Rows adds the DiffColumns
+	ShowDropped     bool // Rows adds the DroppedColumn
+
+	Records []Record // newest first
+}
+
+// Record is one row of the sheet, a co-authored commit has one per author
+type Record struct {
+	Repository  string
+	Hash        string
+	Branch      string // the branch or tag that introduced the commit, set with Options.BranchColumn
+	Date        time.Time
+	Author      string // resolved through .mailmap
+	AuthorEmail string
+	Type        string
+	Scope       string
+	Description string
+	Tickets     []string
+	Stats       *DiffStats // set with Options.DiffStats, nil when unknown
+	Dropped     string     // why the commit isn't counted, eg: merge
+
+	TimeSpent time.Duration // tracked in a trailer or note, 0 = estimated
+	Estimate  Estimate
+}
+
+// Rows returns the records as sheet rows, header first and oldest commit first
+func (r *Report) Rows() [][]string {
+	rows := [][]string{r.Header()}
+	for _, rec := range slices.Backward(r.Records) {
+		rows = append(rows, r.row(rec))
+	}
+	return rows
+}
+
+// Header returns the columns of the report's sheet
+func (r *Report) Header() []string {
+	header := slices.Clone(Columns)
+	if r.BranchColumn {
+		header = append(header, BranchColumn)
+	}
+	if r.HashColumn {
+		header = append(header, HashColumn)
+	}
+	if r.ShortHashColumn {
+		header = append(header, ShortHashColumn)
+	}
+	if r.DiffStats {
+		header = append(header, DiffColumns...)
+	}
+	if r.ShowDropped {
+		header = append(header, DroppedColumn)
+	}
+	if !r.Range.IsZero() {
+		header = append(header, RangeColumn)
+	}
+	return header
+}
+
+// row renders `rec` in the columns of Header
+func (r *Report) row(rec Record) []string {
+	row := []string{
+		rec.Date.Format("2006-01-02 15:04:05"),
+		rec.Author,
+		rec.Type,
+		rec.Scope,
+		rec.Description,
+		formatDuration(rec.Estimate.Duration),
+		rec.Repository,
+		strings.Join(rec.Tickets, ", "),
+		formatHours(rec.Estimate.Duration),
+		estimateSource(rec.Estimate),
+		strconv.Itoa(rec.Estimate.Session),
+		formatHours(rec.Estimate.SessionDuration),
+	}
+	if r.BranchColumn {
+		row = append(row, rec.Branch)
+	}
+	if r.HashColumn {
+		row = append(row, rec.Hash)
+	}
+	if r.ShortHashColumn {
+		row = append(row, shortHash(plumbing.NewHash(rec.Hash)))
+	}
+	if r.DiffStats {
+		row = append(row, diffCells(rec.Stats)...)
+	}
+	if r.ShowDropped {
+		row = append(row, rec.Dropped)
+	}
+	if !r.Range.IsZero() {
+		row = append(row, r.Range.String())
+	}
+	return row
+}
+
+// diffCells renders the DiffColumns, empty when the diff is unknown
+func diffCells(s *DiffStats) []string {
+	if s == nil {
+		return make([]string, len(DiffColumns))
+	}
+	return []string{
+		strconv.Itoa(s.Added),
+		strconv.Itoa(s.Removed),
+		strconv.Itoa(s.Files),
+		strings.Join(s.Dirs, ", "),
+	}
+}
+
+// Meta returns what the server stores next to the uploaded sheet
+func (r *Report) Meta() SheetMeta {
+	meta := SheetMeta{
+		Repo:        r.Repo,
+		Branch:      r.Branch,
+		SinceDays:   r.SinceDays,
+		Period:      r.Period,
+		GeneratedBy: r.GeneratedBy,
+		GeneratedAt: r.GeneratedAt,
+		Description: r.Description,
+	}
+	if !r.Range.Since.IsZero() {
+		meta.Since = &r.Range.Since
+	}
+	if !r.Range.Until.IsZero() {
+		meta.Until = &r.Range.Until
+	}
+	return meta
+}
+
+// ByAuthor splits the report into one per author email, in order of first appearance
+func (r *Report) ByAuthor() []*Report {
+	var reports []*Report
+	byEmail := make(map[string]*Report)
+
+	for _, rec := range r.Records {
+		email := strings.ToLower(rec.AuthorEmail)
+		author, ok := byEmail[email]
+		if !ok {
+			author = &Report{}
+			*author = *r
+			author.Records = nil
+			byEmail[email] = author
+			reports = append(reports, author)
+		}
+		author.Records = append(author.Records, rec)
+	}
+
+	return reports
+}
+
+// TotalHours sums the hours of every record to the cent
+func (r *Report) TotalHours() float64 {
+	rows := r.Rows()
+	return TotalHours(rows[0], rows[1:])
+}
+
+// estimate sets the Estimate of every record, sessions are grouped per author
+// email. Dropped records are left out and get no hours.
+func estimate(records []Record, opts EstimateOptions) {
+	var items []WorkItem
+	var counted []int
+	for i, rec := range records {
+		if rec.Dropped != "" {
+			continue
+		}
+		items = append(items, WorkItem{Author: rec.AuthorEmail, When: rec.Date, Explicit: rec.TimeSpent})
+		counted = append(counted, i)
+	}
+
+	for i, e := range EstimateSessions(items, opts) {
+		records[counted[i]].Estimate = e
+	}
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// how deep FindRepositories looks below the directory it is given
+const scanDepth = 3
+
+type namedRepo struct {
+	name string
+	repo *git.Repository
+}
+
+// repoName names a repository after its directory, eg: sheet-happens.git
+// is sheet-happens
+func repoName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".git")
+}
+
+// openRepositories opens `path` when it is a repository, otherwise the
+// repositories found below it
+func openRepositories(path string) ([]namedRepo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve folder path: %w", err)
+	}
+
+	repo, err := git.PlainOpen(absPath)
+	if err == nil {
+		return []namedRepo{{name: repoName(absPath), repo: repo}}, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	paths, err := FindRepositories(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no git repository found in %s", absPath)
+	}
+
+	var repos []namedRepo
+	for _, p := range paths {
+		repo, err := git.PlainOpen(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open git repository %s: %w", p, err)
+		}
+		repos = append(repos, namedRepo{name: repoName(p), repo: repo})
+	}
+	return repos, nil
+}
+
+// FindRepositories returns the git repositories below `root`, up to three
+// levels deep. Hidden directories and the insides of repositories are skipped.
+func FindRepositories(root string) ([]string, error) {
+	var repos []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && errors.Is(err, fs.ErrPermission) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if isRepository(path) {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		if rel != "." && strings.Count(rel, string(filepath.Separator)) >= scanDepth-1 {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return repos, err
+}
+
+// isRepository reports whether `path` is a worktree or a bare repository
+func isRepository(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return true
+	}
+	_, errHead := os.Stat(filepath.Join(path, "HEAD"))
+	_, errObjects := os.Stat(filepath.Join(path, "objects"))
+	return errHead == nil && errObjects == nil
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// clone clones the repository into `dir`, eg: a fork, its commits continue
+// from the clock of the original
+func (r *testRepo) clone(dir string) *testRepo {
+	r.t.Helper()
+
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: r.dir})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return &testRepo{t: r.t, dir: dir, repo: repo, clock: r.clock, step: r.step, files: r.files}
+}
+
+func TestGenerateRepositories(t *testing.T) {
+	parent := t.TempDir()
+
+	// api-fork is cloned from api after the base work, both go on from there
+	api := newTestRepoAt(t, filepath.Join(parent, "api"))
+	api.commit("Dev <dev@example.com>", "feat: base work")
+	fork := api.clone(filepath.Join(parent, "api-fork"))
+	api.step, fork.step = time.Hour, time.Hour
+	fork.clock = api.clock.Add(-15 * time.Minute)
+	fork.commit("Dev <dev@example.com>", "feat: fork work")
+	api.commit("Dev <dev@example.com>", "feat: api work")
+
+	// the repositories are found below their parent directory
+	rep, err := Generate(context.Background(), Options{Folders: []string{parent}, AllAuthors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(rep.Repos, []string{"api", "api-fork"}) || rep.Repo != "api, api-fork" {
+		t.Errorf("repos = %q, %q", rep.Repos, rep.Repo)
+	}
+
+	rows := rep.Rows()
+	repoCol, descriptionCol := slices.Index(rows[0], "Repository"), slices.Index(rows[0], "Description")
+	got := map[string][]string{}
+	for _, row := range rows[1:] {
+		got[row[descriptionCol]] = append(got[row[descriptionCol]], row[repoCol])
+	}
+	want := map[string][]string{
+		"base work": {"api"}, // in both, counted once
+		"fork work": {"api-fork"},
+		"api work":  {"api"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("rows %q, want %q", got, want)
+	}
+	for description, repos := range want {
+		if !slices.Equal(got[description], repos) {
+			t.Errorf("%q is in %q, want %q", description, got[description], repos)
+		}
+	}
+
+	// 9:00, 9:15 in the fork and 9:30 are one session across the repositories
+	for _, rec := range rep.Records {
+		if rec.Estimate.Session != 1 || rec.Estimate.SessionDuration != time.Hour {
+			t.Errorf("%q is in session %d of %s, want session 1 of 1h", rec.Description, rec.Estimate.Session, rec.Estimate.SessionDuration)
+		}
+	}
+	if d := byDescription(rep)["fork work"].Estimate.Duration; d != 15*time.Minute {
+		t.Errorf("fork work credited %s, want the 15m since the base work", d)
+	}
+}
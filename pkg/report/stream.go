@@ -0,0 +1,384 @@
+package report
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// StreamCSV is Generate for histories too large to hold in memory. Commits are
+// walked newest first and each work session is estimated once no older commit
+// can join it, then set aside in a temporary file. Sessions are numbered per
+// author from 1, oldest first as in Generate, and written to `w` oldest first
+// once the walk is done. Rows of sessions that overlap in time come out
+// session by session rather than interleaved by date. Branch tickets are
+// resolved along the walk and a revert is paired whenever the commit it
+// reverts is in the sheet's range. The returned Report has no Records.
+func StreamCSV(ctx context.Context, opts Options, w io.Writer) (*Report, error) {
+	if opts.BranchColumn {
+		return nil, fmt.Errorf("%w: the branch column needs the whole history and can't be streamed", ErrInvalidOption)
+	}
+	if opts.Merges == MergesCollapse {
+		return nil, fmt.Errorf("%w: collapsing merges needs the whole history and can't be streamed", ErrInvalidOption)
+	}
+
+	g, repos, err := newGenerator(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	rep := g.report(repos)
+
+	// one walk over every repository, a commit in several of them counts once
+	walker := newCommitWalker(g.commitRange.Since)
+	defer walker.Close()
+
+	contexts := make([]*repoContext, len(repos))
+	for i, r := range repos {
+		if contexts[i], err = g.openRepo(r); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		refs, err := sheetRefs(r.repo, opts.Branch, g.refs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		if err := walker.add(r.repo, refs, i); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		if err := g.claimTickets(walker, r.repo, refs, i); err != nil {
+			return nil, fmt.Errorf("%s: failed to read branches: %w", r.name, err)
+		}
+	}
+
+	spill, err := newSessionSpill()
+	if err != nil {
+		return nil, err
+	}
+	defer spill.Close()
+	sessions := newSessionStream(g.estimate, spill.add)
+
+	reverted := make(map[plumbing.Hash]string) // "reverted by" notes for commits not walked yet
+	for {
+		c, i, claim, err := walker.next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sessions.advance(c.Committer.When); err != nil {
+			return nil, err
+		}
+		if !claim.sheet || !g.commitRange.Contains(c.Author.When) {
+			continue
+		}
+
+		info, ok, err := g.streamInfo(ctx, contexts[i], c, reverted)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repos[i].name, err)
+		}
+		if !ok {
+			continue
+		}
+		info.branchTickets = claim.branchTickets()
+		for _, rec := range g.records(contexts[i], c, info) {
+			if err := sessions.add(rec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := sessions.flush(); err != nil {
+		return nil, err
+	}
+	if spill.rows == 0 {
+		return nil, ErrNoCommits
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rep.Header()); err != nil {
+		return nil, err
+	}
+	err = spill.replay(func(rec Record) error {
+		// the stream numbers newest first, counting from the last session
+		if rec.Estimate.Session > 0 {
+			rec.Estimate.Session = sessions.numbered[strings.ToLower(rec.AuthorEmail)] - rec.Estimate.Session + 1
+		}
+		return cw.Write(rep.row(rec))
+	})
+	if err != nil {
+		return nil, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// claimTickets has the walker pass the tickets of the branches that claim
+// the sheet walked from `refs` on to their history, see claimRefs
+func (g *generator) claimTickets(walker *commitWalker, repo *git.Repository, refs []*plumbing.Reference, index int) error {
+	tips, err := g.claimRefs(repo, refs)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range branchRefs(tips) {
+		claim := refClaim{tickets: g.tickets.Find(ref.Name().Short())}
+		claim.untracked = len(claim.tickets) == 0
+		if err := walker.claim(repo, []*plumbing.Reference{ref}, index, claim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionSpill keeps the sessions of a stream in a temporary file, one JSON
+// line each, to be written out in reverse once the walk is done
+type sessionSpill struct {
+	file    *os.File
+	offsets []int64 // where each session starts, the file ends the last one
+	size    int64
+	rows    int
+}
+
+func newSessionSpill() (*sessionSpill, error) {
+	file, err := os.CreateTemp("", "sheethappens-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	return &sessionSpill{file: file}, nil
+}
+
+// add stores the records of a session, given newest first
+func (s *sessionSpill) add(records []Record) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err := s.file.Write(data); err != nil {
+		return err
+	}
+	s.offsets = append(s.offsets, s.size)
+	s.size += int64(len(data))
+	s.rows += len(records)
+	return nil
+}
+
+// replay calls `fn` for every stored record, oldest session and record first
+func (s *sessionSpill) replay(fn func(rec Record) error) error {
+	end := s.size
+	for _, offset := range slices.Backward(s.offsets) {
+		data := make([]byte, end-offset)
+		if _, err := s.file.ReadAt(data, offset); err != nil {
+			return err
+		}
+		end = offset
+
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			return err
+		}
+		for _, rec := range slices.Backward(records) {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close removes the temporary file
+func (s *sessionSpill) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+// streamInfo runs the paths filter and the noise rules on `c` as it is walked,
+// false when the commit isn't in the sheet. A revert is walked before the
+// commit it reverts, its note waits in `reverted` until that one comes by.
+func (g *generator) streamInfo(ctx context.Context, rc *repoContext, c *object.Commit, reverted map[plumbing.Hash]string) (commitInfo, bool, error) {
+	var info commitInfo
+	if g.opts.DiffStats || len(g.opts.Paths) > 0 {
+		stats, err := commitDiff(ctx, c, g.opts.DiffStats)
+		if err != nil {
+			return info, false, fmt.Errorf("failed to diff %s: %w", c.Hash, err)
+		}
+		if len(g.opts.Paths) > 0 && !stats.Touches(g.opts.Paths) {
+			return info, false, nil
+		}
+		info.stats = stats
+	}
+
+	info.dropped = g.rules.reason(c)
+	if g.rules.reverts == RevertsKeep {
+		return info, true, nil
+	}
+
+	pair := func(text string) {
+		switch {
+		case g.rules.reverts == RevertsDrop && info.dropped == "":
+			info.dropped = text
+		case g.rules.reverts == RevertsPair && info.note == "":
+			info.note = text
+		}
+	}
+
+	if text, ok := reverted[c.Hash]; ok {
+		delete(reverted, c.Hash)
+		pair(text)
+	}
+	if original, ok := revertOf(c); ok {
+		in, err := g.inSheet(ctx, rc, original)
+		if err != nil {
+			return info, false, err
+		}
+		if in {
+			setOnce(reverted, original, "reverted by "+shortHash(c.Hash))
+			pair("reverts " + shortHash(original))
+		}
+	}
+
+	return info, true, nil
+}
+
+// inSheet reports whether the commit `hash` is within the sheet's range and
+// paths, without walking to it
+func (g *generator) inSheet(ctx context.Context, rc *repoContext, hash plumbing.Hash) (bool, error) {
+	c, err := rc.repo.CommitObject(hash)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+	if !g.commitRange.Contains(c.Author.When) {
+		return false, nil
+	}
+	if len(g.opts.Paths) == 0 {
+		return true, nil
+	}
+
+	stats, err := commitDiff(ctx, c, false)
+	if err != nil {
+		return false, fmt.Errorf("failed to diff %s: %w", c.Hash, err)
+	}
+	return stats.Touches(g.opts.Paths), nil
+}
+
+// sessionStream estimates records arriving newest first like estimate does,
+// and hands the records of each author's session to `emit` once it has ended.
+// Dropped records get no hours and are emitted right away.
+type sessionStream struct {
+	opts     EstimateOptions
+	idle     time.Duration
+	open     map[string]*openSession // by lowercase author email
+	numbered map[string]int          // sessions emitted per author
+	emit     func(records []Record) error
+}
+
+// openSession is the records of an author's session that may still grow
+type openSession struct {
+	author  string
+	records []Record
+	oldest  time.Time
+}
+
+func newSessionStream(opts EstimateOptions, emit func(records []Record) error) *sessionStream {
+	return &sessionStream{
+		opts:     opts,
+		idle:     opts.IdleThreshold,
+		open:     make(map[string]*openSession),
+		numbered: make(map[string]int),
+		emit:     emit,
+	}
+}
+
+func (s *sessionStream) add(rec Record) error {
+	if rec.Dropped != "" {
+		return s.emit([]Record{rec})
+	}
+
+	author := strings.ToLower(rec.AuthorEmail)
+	session := s.open[author]
+	if session != nil && session.oldest.Sub(rec.Date) > s.idle {
+		if err := s.close(session); err != nil {
+			return err
+		}
+		session = nil
+	}
+	if session == nil {
+		session = &openSession{author: author, oldest: rec.Date}
+		s.open[author] = session
+	}
+
+	session.records = append(session.records, rec)
+	if rec.Date.Before(session.oldest) {
+		session.oldest = rec.Date
+	}
+	return nil
+}
+
+// advance closes the sessions no commit older than `when` can join, commits
+// are authored before they are committed
+func (s *sessionStream) advance(when time.Time) error {
+	return s.closeWhere(func(session *openSession) bool { return session.oldest.Sub(when) > s.idle })
+}
+
+// flush closes every open session once the walk is done
+func (s *sessionStream) flush() error {
+	return s.closeWhere(func(*openSession) bool { return true })
+}
+
+// closeWhere closes the matching sessions, the most recent first
+func (s *sessionStream) closeWhere(ended func(session *openSession) bool) error {
+	var sessions []*openSession
+	for _, session := range s.open {
+		if ended(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *openSession) int {
+		return cmp.Or(b.oldest.Compare(a.oldest), strings.Compare(a.author, b.author))
+	})
+
+	for _, session := range sessions {
+		if err := s.close(session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close estimates the records of `session` and emits them newest first.
+// Records that arrived out of order may still split into several sessions.
+func (s *sessionStream) close(session *openSession) error {
+	delete(s.open, session.author)
+
+	records := session.records
+	slices.SortStableFunc(records, func(a, b Record) int { return b.Date.Compare(a.Date) })
+	estimate(records, s.opts)
+
+	// estimate numbers oldest first, the stream can only number newest first
+	count := 0
+	for _, rec := range records {
+		count = max(count, rec.Estimate.Session)
+	}
+	for i := range records {
+		records[i].Estimate.Session = s.numbered[session.author] + count - records[i].Estimate.Session + 1
+	}
+	s.numbered[session.author] += count
+
+	return s.emit(records)
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStreamCSVMatchesGenerate(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work")
+	r.commit("Dev <dev@example.com>", "feat: more base work")
+	r.checkout("PROJ-7-login", true)
+	r.clock = r.clock.Add(3 * time.Hour) // a new session
+	r.commit("Dev <dev@example.com>", "feat(auth): login form")
+	r.commit("Dev <dev@example.com>", "fix(auth): login redirect")
+	r.checkout("main", false)
+	r.clock = r.clock.Add(3 * time.Hour)
+	r.commit("Dev <dev@example.com>", "fix: mainline fix")
+
+	for _, branch := range []string{"", "PROJ-7-login", "main"} {
+		t.Run("branch "+branch, func(t *testing.T) {
+			want := r.generate(t, Options{Branch: branch}).Rows()
+
+			var buf bytes.Buffer
+			opts := Options{Folders: []string{r.dir}, AllAuthors: true, Branch: branch}
+			if _, err := StreamCSV(context.Background(), opts, &buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.EqualFunc(got, want, slices.Equal) {
+				t.Errorf("streamed rows differ from Generate\ngot:  %v\nwant: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamCSVNoCommits(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work")
+
+	opts := Options{Folders: []string{r.dir}, AllAuthors: true, Range: DateRange{Since: r.clock.AddDate(1, 0, 0)}}
+	if _, err := StreamCSV(context.Background(), opts, &bytes.Buffer{}); err != ErrNoCommits {
+		t.Fatalf("got %v, want ErrNoCommits", err)
+	}
+}
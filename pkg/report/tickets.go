@@ -0,0 +1,218 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// DefaultTicketPatterns match Jira style keys and GitHub style issue numbers, eg: PROJ-123 or #456
+var DefaultTicketPatterns = []string{`\b[A-Z]{2}[A-Z0-9]*-[1-9]\d*\b`, `#\d+\b`}
+
+// standards look like Jira keys, eg: UTF-8 or SHA-256, and aren't tickets
+// when the default patterns are used
+var standardNames = []string{"AES", "CRC", "ECMA", "IEEE", "ISO", "RFC", "SHA", "UTF"}
+
+// TicketColumn holds the ticket keys of a commit, separated by ", "
+const TicketColumn = "Ticket"
+
+// TicketMatcher extracts ticket keys. A pattern with a capture group yields
+// its first group, otherwise the whole match.
+type TicketMatcher struct {
+	patterns []*regexp.Regexp
+	skip     []string // prefixes of keys that aren't tickets
+}
+
+func NewTicketMatcher(patterns []string) (*TicketMatcher, error) {
+	m := &TicketMatcher{}
+	if len(patterns) == 0 {
+		patterns = DefaultTicketPatterns
+		m.skip = standardNames
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", pattern, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// Find returns the ticket keys found in `texts`, without duplicates and in
+// order of appearance
+func (m *TicketMatcher) Find(texts ...string) []string {
+	var tickets []string
+	for _, text := range texts {
+		for _, re := range m.patterns {
+			for _, match := range re.FindAllStringSubmatch(text, -1) {
+				ticket := match[0]
+				if len(match) > 1 {
+					ticket = match[1]
+				}
+				if ticket != "" && !m.skipped(ticket) && !slices.Contains(tickets, ticket) {
+					tickets = append(tickets, ticket)
+				}
+			}
+		}
+	}
+	return tickets
+}
+
+func (m *TicketMatcher) skipped(ticket string) bool {
+	prefix, _, _ := strings.Cut(ticket, "-")
+	return slices.Contains(m.skip, prefix)
+}
+
+// FromBranches returns the ticket keys in the names of the local branches
+// that contain each of `commits`, looking no further back than `since` when
+// it is set. A commit that is also on a branch without a ticket, eg: main,
+// belongs to no branch ticket.
+func (m *TicketMatcher) FromBranches(repo *git.Repository, since time.Time, commits []*object.Commit) (map[plumbing.Hash][]string, error) {
+	iter, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []*plumbing.Reference
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m.FromRefs(repo, branches, since, commits)
+}
+
+// FromRefs is FromBranches for any list of branches, eg: remote-tracking
+// ones. With nil `commits` every commit since `since` is looked up, only the
+// commits with a ticket are kept in memory. Otherwise no branch is walked past
+// the oldest of `commits`, even without `since`.
+func (m *TicketMatcher) FromRefs(repo *git.Repository, branches []*plumbing.Reference, since time.Time, commits []*object.Commit) (map[plumbing.Hash][]string, error) {
+	tickets := make(map[plumbing.Hash][]string)
+
+	var wanted map[plumbing.Hash]bool
+	if commits != nil {
+		if len(commits) == 0 {
+			return tickets, nil
+		}
+
+		wanted = make(map[plumbing.Hash]bool, len(commits))
+		oldest := commits[0].Committer.When
+		for _, c := range commits {
+			wanted[c.Hash] = true
+			if c.Committer.When.Before(oldest) {
+				oldest = c.Committer.When
+			}
+		}
+		if oldest.After(since) {
+			since = oldest
+		}
+	}
+
+	var untracked []*plumbing.Reference // branches without a ticket
+
+	for _, ref := range branches {
+		branchTickets := m.Find(ref.Name().Short())
+		if len(branchTickets) == 0 {
+			untracked = append(untracked, ref)
+			continue
+		}
+
+		err := walkLog(repo, ref.Hash(), since, func(c *object.Commit) error {
+			if wanted == nil || wanted[c.Hash] {
+				tickets[c.Hash] = append(tickets[c.Hash], branchTickets...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ref := range untracked {
+		err := walkLog(repo, ref.Hash(), since, func(c *object.Commit) error {
+			delete(tickets, c.Hash)
+			if len(tickets) == 0 {
+				return storer.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tickets, nil
+}
+
+// TicketSummary rolls the Hours of the sheet `records` up per ticket, most
+// hours first. A commit with several tickets splits its hours between them,
+// dropped commits are left out.
+func TicketSummary(records [][]string) [][]string {
+	if len(records) == 0 {
+		return nil
+	}
+	ticketCol := slices.Index(records[0], TicketColumn)
+	hoursCol := slices.Index(records[0], "Hours")
+	if ticketCol < 0 || hoursCol < 0 {
+		return nil
+	}
+	droppedCol := slices.Index(records[0], DroppedColumn)
+
+	type ticketHours struct {
+		ticket  string
+		commits int
+		hours   float64
+	}
+	var totals []*ticketHours
+	byTicket := make(map[string]*ticketHours)
+	var total float64
+	var commits int
+
+	for _, row := range records[1:] {
+		if droppedCol >= 0 && row[droppedCol] != "" {
+			continue
+		}
+		commits++
+		hours, _ := strconv.ParseFloat(row[hoursCol], 64)
+		total += hours
+
+		tickets := strings.Split(row[ticketCol], ", ")
+		if row[ticketCol] == "" {
+			tickets = []string{"(none)"}
+		}
+		for _, ticket := range tickets {
+			t, ok := byTicket[ticket]
+			if !ok {
+				t = &ticketHours{ticket: ticket}
+				byTicket[ticket] = t
+				totals = append(totals, t)
+			}
+			t.commits++
+			t.hours += hours / float64(len(tickets))
+		}
+	}
+
+	slices.SortStableFunc(totals, func(a, b *ticketHours) int { return cmp.Compare(b.hours, a.hours) })
+
+	summary := [][]string{{TicketColumn, "Commits", "Hours"}}
+	for _, t := range totals {
+		summary = append(summary, []string{t.ticket, strconv.Itoa(t.commits), fmt.Sprintf("%.2f", t.hours)})
+	}
+	summary = append(summary, []string{"Total", strconv.Itoa(commits), fmt.Sprintf("%.2f", total)})
+
+	return summary
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestTicketMatcherFind(t *testing.T) {
+	tests := map[string]struct {
+		patterns []string
+		texts    []string
+		want     []string
+	}{
+		"jira key": {
+			texts: []string{"feat: PROJ-123 login form"},
+			want:  []string{"PROJ-123"},
+		},
+		"branch name": {
+			texts: []string{"PROJ-7-login"},
+			want:  []string{"PROJ-7"},
+		},
+		"issue number": {
+			texts: []string{"fix: crash on save, closes #456"},
+			want:  []string{"#456"},
+		},
+		"several texts without duplicates": {
+			texts: []string{"PROJ-1 and AB2-9", "PROJ-1 again"},
+			want:  []string{"PROJ-1", "AB2-9"},
+		},
+		"standards": {
+			texts: []string{"chore: read UTF-8, hash with SHA-256 per RFC-7231 and ISO-8601"},
+		},
+		"not keys": {
+			texts: []string{"A-1 is too short, PROJ-0 starts with zero and proj-12 is lower case"},
+		},
+		"custom pattern": {
+			patterns: []string{`\bTASK-(\d+)\b`},
+			texts:    []string{"feat: TASK-42 and PROJ-1"},
+			want:     []string{"42"},
+		},
+		"custom patterns keep standards": {
+			patterns: []string{`\b[A-Z]+-\d+\b`},
+			texts:    []string{"UTF-8"},
+			want:     []string{"UTF-8"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewTicketMatcher(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Find(tt.texts...); !slices.Equal(got, tt.want) {
+				t.Errorf("Find(%q) = %v, want %v", tt.texts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTicketMatcherInvalid(t *testing.T) {
+	if _, err := NewTicketMatcher([]string{`PROJ-(\d+`}); err == nil {
+		t.Fatal("got no error for an invalid pattern")
+	}
+}
+
+func TestTicketSummary(t *testing.T) {
+	records := [][]string{
+		{"Description", TicketColumn, "Hours", DroppedColumn},
+		{"login form", "PROJ-7", "2.00", ""},
+		{"login and signup", "PROJ-7, PROJ-8", "1.00", ""},
+		{"typo", "", "0.50", ""},
+		{"merge", "PROJ-8", "3.00", "merge"},
+	}
+
+	want := [][]string{
+		{TicketColumn, "Commits", "Hours"},
+		{"PROJ-7", "2", "2.50"},
+		{"PROJ-8", "1", "0.50"},
+		{"(none)", "1", "0.50"},
+		{"Total", "3", "3.50"},
+	}
+
+	got := TicketSummary(records)
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("TicketSummary() = %v, want %v", got, want)
+	}
+
+	if got := TicketSummary([][]string{{"Description", "Hours"}}); got != nil {
+		t.Errorf("TicketSummary() without a ticket column = %v, want nil", got)
+	}
+}
+
+func TestFromRefsSince(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Dev <dev@example.com>", "feat: base work") // 9:00
+	r.checkout("PROJ-7-login", true)
+	older := r.commit("Dev <dev@example.com>", "feat: login form")   // 9:30
+	newer := r.commit("Dev <dev@example.com>", "feat: login button") // 10:00
+	r.checkout("main", false)
+
+	branches := []*plumbing.Reference{}
+	for _, name := range []string{"main", "PROJ-7-login"} {
+		ref, err := r.repo.Reference(plumbing.NewBranchReferenceName(name), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		branches = append(branches, ref)
+	}
+	commit := func(hash plumbing.Hash) *object.Commit {
+		c, err := r.repo.CommitObject(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	at := func(hour, minute int) time.Time { return time.Date(2025, 4, 1, hour, minute, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		since   time.Time
+		commits []*object.Commit
+		want    []plumbing.Hash
+	}{
+		"every commit":           {want: []plumbing.Hash{older, newer}},
+		"every commit since":     {since: at(9, 45), want: []plumbing.Hash{newer}},
+		"since before the range": {since: at(8, 0), want: []plumbing.Hash{older, newer}},
+		"given commits":          {commits: []*object.Commit{commit(newer)}, want: []plumbing.Hash{newer}},
+		"given older commit":     {commits: []*object.Commit{commit(older)}, want: []plumbing.Hash{older}},
+		"no commits":             {commits: []*object.Commit{}, want: []plumbing.Hash{}},
+	}
+
+	m, err := NewTicketMatcher(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tickets, err := m.FromRefs(r.repo, branches, tt.since, tt.commits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tickets) != len(tt.want) {
+				t.Errorf("got tickets for %d commits, want %d", len(tickets), len(tt.want))
+			}
+			for _, hash := range tt.want {
+				if !slices.Equal(tickets[hash], []string{"PROJ-7"}) {
+					t.Errorf("%s has tickets %v, want PROJ-7", hash, tickets[hash])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	DefaultTimeKey  = "Time-Spent"
+	DefaultNotesRef = "refs/notes/commits"
+)
+
+// eg: 1h30m, 1h 30m, 1.5h, 90m or 90 (minutes). Alternation takes the first
+// unit that matches so the longer spellings come first.
+var durationPartRegex = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(hours?|hrs|hr|h|minutes?|mins|min|m)?`)
+
+// TimeSpent reads explicitly tracked time from commit trailers and git notes
+type TimeSpent struct {
+	keys  []string
+	notes map[plumbing.Hash]string
+}
+
+// LoadTimeSpent reads the notes under `notesRef` of `repo`, a missing notes
+// ref is not an error. `keys` are the trailer names to look for.
+func LoadTimeSpent(repo *git.Repository, keys []string, notesRef string) (*TimeSpent, error) {
+	if len(keys) == 0 {
+		keys = []string{DefaultTimeKey}
+	}
+	if notesRef == "" {
+		notesRef = DefaultNotesRef
+	}
+	t := &TimeSpent{keys: keys, notes: make(map[plumbing.Hash]string)}
+
+	ref, err := repo.Reference(plumbing.ReferenceName(notesRef), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("invalid notes ref %s: %w", notesRef, err)
+	}
+	tree, err := commit.Tree()
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return t, nil // left out of a shallow or partial clone
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// notes are named after the commit they annotate, possibly split in
+	// fanout directories, eg: ab/cdef0123...
+	err = tree.Files().ForEach(func(f *object.File) error {
+		hash := strings.ReplaceAll(f.Name, "/", "")
+		if len(hash) != 40 {
+			return nil
+		}
+
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		t.notes[plumbing.NewHash(hash)] = string(content)
+		return nil
+	})
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return t, nil
+	}
+
+	return t, err
+}
+
+// Of returns the time tracked for `c`, its note wins over its message since
+// notes are added afterwards. The bool is false when no time was tracked.
+func (t *TimeSpent) Of(c *object.Commit) (time.Duration, bool) {
+	if d, ok := t.parse(t.notes[c.Hash]); ok {
+		return d, true
+	}
+	return t.parse(c.Message)
+}
+
+// parse returns the last `Key: duration` line of `text` with one of the keys
+func (t *TimeSpent) parse(text string) (time.Duration, bool) {
+	var found time.Duration
+	ok := false
+
+	for _, line := range strings.Split(text, "\n") {
+		key, value, cut := strings.Cut(line, ":")
+		if !cut {
+			continue
+		}
+		for _, k := range t.keys {
+			if !strings.EqualFold(strings.TrimSpace(key), k) {
+				continue
+			}
+			if d, err := ParseTimeSpent(value); err == nil {
+				found, ok = d, true
+			}
+		}
+	}
+
+	return found, ok
+}
+
+// ParseTimeSpent parses durations as people write them, eg: "1h30m",
+// "1h 30m", "1.5h", "45min" or "90" (minutes)
+func ParseTimeSpent(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	rest := value
+	for _, match := range durationPartRegex.FindAllStringSubmatch(value, -1) {
+		n, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, err
+		}
+
+		unit := time.Minute
+		if strings.HasPrefix(strings.ToLower(match[2]), "h") {
+			unit = time.Hour
+		}
+		total += time.Duration(n * float64(unit))
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+
+	if strings.TrimSpace(rest) != "" || total <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return total, nil
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestParseTimeSpent(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1h30m":      90 * time.Minute,
+		"1h 30m":     90 * time.Minute,
+		"1.5h":       90 * time.Minute,
+		"90m":        90 * time.Minute,
+		"90":         90 * time.Minute,
+		"45min":      45 * time.Minute,
+		"45 mins":    45 * time.Minute,
+		"30 minutes": 30 * time.Minute,
+		"1 minute":   time.Minute,
+		"2 hrs":      2 * time.Hour,
+		"2hr":        2 * time.Hour,
+		"1 hour":     time.Hour,
+		"1.5 hours":  90 * time.Minute,
+		"2H 15M":     135 * time.Minute,
+		" 1h ":       time.Hour,
+	}
+
+	for value, want := range tests {
+		t.Run(value, func(t *testing.T) {
+			got, err := ParseTimeSpent(value)
+			if err != nil {
+				t.Fatalf("ParseTimeSpent(%q): %v", value, err)
+			}
+			if got != want {
+				t.Errorf("ParseTimeSpent(%q) = %s, want %s", value, got, want)
+			}
+		})
+	}
+}
+
+func TestParseTimeSpentInvalid(t *testing.T) {
+	for _, value := range []string{"", "  ", "soon", "1 day", "2 hoursish", "0", "0h", "1h and a bit"} {
+		t.Run(value, func(t *testing.T) {
+			if got, err := ParseTimeSpent(value); err == nil {
+				t.Errorf("ParseTimeSpent(%q) = %s, want an error", value, got)
+			}
+		})
+	}
+}
+
+func TestTimeSpentOf(t *testing.T) {
+	noted := plumbing.NewHash("1111111111111111111111111111111111111111")
+	spent := &TimeSpent{
+		keys:  []string{DefaultTimeKey, "Spent"},
+		notes: map[plumbing.Hash]string{noted: "Time-Spent: 2h\n"},
+	}
+
+	tests := map[string]struct {
+		commit *object.Commit
+		want   time.Duration
+		ok     bool
+	}{
+		"trailer": {
+			commit: &object.Commit{Message: "feat: login\n\nTime-Spent: 45min\n"},
+			want:   45 * time.Minute,
+			ok:     true,
+		},
+		"other key, any case": {
+			commit: &object.Commit{Message: "fix: typo\n\nspent: 1 hour\n"},
+			want:   time.Hour,
+			ok:     true,
+		},
+		"last line wins": {
+			commit: &object.Commit{Message: "fix: typo\n\nTime-Spent: 1h\nTime-Spent: 20m\n"},
+			want:   20 * time.Minute,
+			ok:     true,
+		},
+		"invalid value": {
+			commit: &object.Commit{Message: "fix: typo\n\nTime-Spent: a while\n"},
+		},
+		"untracked": {
+			commit: &object.Commit{Message: "fix: typo\n\nReviewed-by: someone\n"},
+		},
+		"note over trailer": {
+			commit: &object.Commit{Hash: noted, Message: "feat: login\n\nTime-Spent: 45min\n"},
+			want:   2 * time.Hour,
+			ok:     true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := spent.Of(tt.commit)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Of() = %s, %v, want %s, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type CSVuploadResponse struct {
+	Success bool    `json:"success"`
+	Payload Payload `json:"payload"`
+}
+
+type Payload struct {
+	Filename string `json:"filename"`
+	Message  string `json:"message"`
+}
+
+// LoadAPIToken reads the personal API token from SHEET_HAPPENS_TOKEN or,
+// when unset, from the `sheethappens/token` file in the user config directory
+func LoadAPIToken() (string, error) {
+	if token := strings.TrimSpace(os.Getenv("SHEET_HAPPENS_TOKEN")); token != "" {
+		return token, nil
+	}
+
+	path, err := APITokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no API token found, set SHEET_HAPPENS_TOKEN or save one to %s", path)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func APITokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sheethappens", "token"), nil
+}
+
+// Uploader sends a rendered sheet somewhere and returns where it can be found
+type Uploader interface {
+	Upload(ctx context.Context, filename string, data io.Reader, meta SheetMeta) (string, error)
+}
+
+// HTTPUploader uploads sheets to a Sheet Happens server, the file name
+// extension tells the server whether `data` is CSV or JSON
+type HTTPUploader struct {
+	BackendURL string
+	Token      string       // personal API token, see LoadAPIToken
+	Client     *http.Client // nil = http.DefaultClient
+	Draft      bool         // upload as a draft instead of submitting for review
+}
+
+// Upload posts the sheet to /csv and returns the id the server stored it
+// under. The body is streamed from `data` as the request is sent.
+func (u *HTTPUploader) Upload(ctx context.Context, filename string, data io.Reader, meta SheetMeta) (string, error) {
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode meta: %w", err)
+	}
+
+	body, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadBody(writer, filename, data, metaJSON))
+	}()
+	// ends the writer when the request fails before reading the whole body
+	defer body.Close()
+
+	url := u.BackendURL + "/csv"
+	if u.Draft {
+		url += "?draft=true"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+u.Token)
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upload failed: %s", string(respBody))
+	}
+
+	var res CSVuploadResponse
+	_ = json.Unmarshal(respBody, &res)
+
+	return res.Payload.Filename, nil
+}
+
+// writeUploadBody writes the multipart form of Upload
+func writeUploadBody(writer *multipart.Writer, filename string, data io.Reader, metaJSON []byte) error {
+	if err := writer.WriteField("meta", string(metaJSON)); err != nil {
+		return fmt.Errorf("failed to write meta field: %w", err)
+	}
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+
+	if _, err := io.Copy(part, data); err != nil {
+		return fmt.Errorf("failed to copy sheet data: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return nil
+}
+
+// URL returns where the uploaded sheet `id` is served
+func (u *HTTPUploader) URL(id string) string {
+	return u.BackendURL + "/csv/" + id
+}
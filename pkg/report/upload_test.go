@@ -0,0 +1,79 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPUploaderUpload(t *testing.T) {
+	sheet := strings.Repeat("2025-04-01 09:00:00,Dev,feat,,login form,0.5\n", 10000)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/csv" || r.URL.Query().Get("draft") != "true" {
+			t.Errorf("got %s, want /csv?draft=true", r.URL)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sh_token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.ContentLength != -1 {
+			t.Errorf("ContentLength = %d, want a streamed body", r.ContentLength)
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("no file: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "1_repo_1234_log.csv" || string(data) != sheet {
+			t.Errorf("got %s with %d bytes, want the sheet", header.Filename, len(data))
+		}
+
+		var meta SheetMeta
+		if err := json.Unmarshal([]byte(r.FormValue("meta")), &meta); err != nil || meta.Repo != "repo" {
+			t.Errorf("meta = %q, %v", r.FormValue("meta"), err)
+		}
+
+		_ = json.NewEncoder(w).Encode(CSVuploadResponse{Success: true, Payload: Payload{Filename: "1_repo_1234_log"}})
+	}))
+	defer srv.Close()
+
+	u := &HTTPUploader{BackendURL: srv.URL, Token: "sh_token", Draft: true}
+	id, err := u.Upload(context.Background(), "1_repo_1234_log.csv", strings.NewReader(sheet), SheetMeta{Repo: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "1_repo_1234_log" {
+		t.Errorf("id = %q", id)
+	}
+}
+
+// failingReader fails after the first read
+type failingReader struct{ read bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("disk gone")
+	}
+	r.read = true
+	return copy(p, "Date\n"), nil
+}
+
+func TestHTTPUploaderUploadReadError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	u := &HTTPUploader{BackendURL: srv.URL}
+	_, err := u.Upload(context.Background(), "sheet.csv", &failingReader{}, SheetMeta{})
+	if err == nil || !strings.Contains(err.Error(), "disk gone") {
+		t.Fatalf("got %v, want the read error", err)
+	}
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"container/heap"
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	graphformat "github.com/go-git/go-git/v5/plumbing/format/commitgraph/v2"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// commitWalker visits the commits reachable from a set of refs, in one or
+// more repositories, newest commit time first. The walk ends at the first
+// commit older than `since`: everything still queued is older, so large
+// histories are never read past the start of the sheet. Repositories with a
+// commit-graph file are walked through it and only the commits that are
+// returned are read from the object store.
+//
+// Each commit comes with the refClaim of the refs that reach it, passed on
+// from child to parent and only held for queued commits. A parent walked
+// before one of its children, which only clock skew causes, misses that
+// child's claim.
+type commitWalker struct {
+	since   time.Time
+	queue   nodeQueue
+	visited map[plumbing.Hash]bool // a commit in several refs or clones is visited once
+	claims  map[plumbing.Hash]refClaim
+	graphs  []io.Closer
+}
+
+// refClaim is what reaches a commit: the refs of the sheet, the tickets of
+// branches named after one, or a branch without a ticket, eg: main, after
+// which the commit belongs to no branch ticket as in FromRefs
+type refClaim struct {
+	sheet     bool
+	untracked bool
+	tickets   []string
+}
+
+// merge returns the claim of a commit reached by both `c` and `other`
+func (c refClaim) merge(other refClaim) refClaim {
+	merged := refClaim{sheet: c.sheet || other.sheet, untracked: c.untracked || other.untracked}
+	if !merged.untracked {
+		merged.tickets = slices.Clone(c.tickets)
+		for _, ticket := range other.tickets {
+			if !slices.Contains(merged.tickets, ticket) {
+				merged.tickets = append(merged.tickets, ticket)
+			}
+		}
+	}
+	return merged
+}
+
+// branchTickets returns the tickets of the branches that claim the commit
+func (c refClaim) branchTickets() []string {
+	if c.untracked {
+		return nil
+	}
+	return c.tickets
+}
+
+// walkItem is a queued commit and the index of the repository it is from
+type walkItem struct {
+	node commitgraph.CommitNode
+	repo int
+}
+
+func newCommitWalker(since time.Time) *commitWalker {
+	return &commitWalker{since: since, visited: make(map[plumbing.Hash]bool), claims: make(map[plumbing.Hash]refClaim)}
+}
+
+// add queues the commits the sheet's `refs` point at, returned commits carry
+// `index`
+func (w *commitWalker) add(repo *git.Repository, refs []*plumbing.Reference, index int) error {
+	return w.claim(repo, refs, index, refClaim{sheet: true})
+}
+
+// claim queues the commits `refs` point at with `claim`, to be passed on to
+// their history. Refs must all be claimed before the walk starts.
+func (w *commitWalker) claim(repo *git.Repository, refs []*plumbing.Reference, index int, claim refClaim) error {
+	nodes := w.nodeIndex(repo)
+
+	for _, ref := range refs {
+		tip, err := peelCommit(repo, ref)
+		if err != nil {
+			return err
+		}
+		if tip.IsZero() {
+			continue
+		}
+		if w.visited[tip] {
+			w.claims[tip] = w.claims[tip].merge(claim)
+			continue
+		}
+
+		node, err := nodes.Get(tip)
+		if err != nil {
+			if ignoreMissing(err) == nil {
+				continue // left out of a partial clone
+			}
+			return err
+		}
+		w.push(node, index, claim)
+	}
+	return nil
+}
+
+// nodeIndex reads commits through the repository's commit-graph when it has
+// one, eg: after `git commit-graph write` or `git gc`
+func (w *commitWalker) nodeIndex(repo *git.Repository) commitgraph.CommitNodeIndex {
+	if fs, ok := repo.Storer.(*filesystem.Storage); ok {
+		if graph, err := graphformat.OpenChainOrFileIndex(fs.Filesystem()); err == nil {
+			w.graphs = append(w.graphs, graph)
+			return commitgraph.NewGraphCommitNodeIndex(graph, repo.Storer)
+		}
+	}
+	return commitgraph.NewObjectCommitNodeIndex(repo.Storer)
+}
+
+func (w *commitWalker) push(node commitgraph.CommitNode, repo int, claim refClaim) {
+	w.visited[node.ID()] = true
+	w.claims[node.ID()] = claim
+	heap.Push(&w.queue, walkItem{node: node, repo: repo})
+}
+
+// next returns the next commit, the index of its repository and the claim of
+// the refs that reach it, io.EOF once the history or the sheet's range is
+// exhausted
+func (w *commitWalker) next(ctx context.Context) (*object.Commit, int, refClaim, error) {
+	for w.queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, refClaim{}, err
+		}
+
+		item := heap.Pop(&w.queue).(walkItem)
+		if !w.since.IsZero() && item.node.CommitTime().Before(w.since) {
+			break
+		}
+		claim := w.claims[item.node.ID()]
+		delete(w.claims, item.node.ID())
+
+		// parents cut off by a shallow or partial clone end that line of history
+		for i := range item.node.NumParents() {
+			parent, err := item.node.ParentNode(i)
+			if err != nil {
+				if ignoreMissing(err) == nil {
+					continue
+				}
+				return nil, 0, refClaim{}, err
+			}
+			switch queued, ok := w.claims[parent.ID()]; {
+			case !w.visited[parent.ID()]:
+				w.push(parent, item.repo, claim)
+			case ok:
+				w.claims[parent.ID()] = queued.merge(claim)
+			}
+		}
+
+		c, err := item.node.Commit()
+		if err != nil {
+			if ignoreMissing(err) == nil {
+				continue
+			}
+			return nil, 0, refClaim{}, err
+		}
+		return c, item.repo, claim, nil
+	}
+
+	return nil, 0, refClaim{}, io.EOF
+}
+
+func (w *commitWalker) Close() {
+	for _, graph := range w.graphs {
+		graph.Close()
+	}
+	w.graphs = nil
+}
+
+// nodeQueue is a max-heap on commit time
+type nodeQueue []walkItem
+
+func (q nodeQueue) Len() int { return len(q) }
+func (q nodeQueue) Less(i, j int) bool {
+	return q[i].node.CommitTime().After(q[j].node.CommitTime())
+}
+func (q nodeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x any)   { *q = append(*q, x.(walkItem)) }
+func (q *nodeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
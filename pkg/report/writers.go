@@ -0,0 +1,286 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// output formats of a generated sheet
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatXLSX     = "xlsx"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var Formats = []string{FormatCSV, FormatJSON, FormatXLSX, FormatMarkdown, FormatHTML}
+
+// FormatExtension returns the file extension of `format`, eg: "md"
+func FormatExtension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// Write renders the report to `w` in `format`
+func (r *Report) Write(w io.Writer, format string) error {
+	return WriteSheet(w, format, r.Rows(), r.Meta())
+}
+
+// WriteSheet writes `records`, header first, to `w` in `format`
+func WriteSheet(w io.Writer, format string, records [][]string, meta SheetMeta) error {
+	switch format {
+	case FormatCSV, "":
+		return writeCSV(w, records)
+	case FormatJSON:
+		return writeJSON(w, records, meta)
+	case FormatXLSX:
+		return writeXLSX(w, records, meta)
+	case FormatMarkdown:
+		return writeMarkdown(w, records, meta)
+	case FormatHTML:
+		return writeHTML(w, records, meta)
+	default:
+		return fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, records [][]string, meta SheetMeta) error {
+	sheet := SheetToJSON(records[0], records[1:])
+	sheet.Meta = &meta
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sheet)
+}
+
+// SheetToJSON wraps a sheet in its JSON form
+func SheetToJSON(header []string, rows [][]string) SheetJSON {
+	if rows == nil {
+		rows = [][]string{}
+	}
+	return SheetJSON{Header: header, Rows: rows, TotalHours: TotalHours(header, rows)}
+}
+
+// TotalHours sums the Hours column to the cent, 0 for sheets without one
+func TotalHours(header []string, rows [][]string) float64 {
+	col := slices.Index(header, "Hours")
+	if col < 0 {
+		return 0
+	}
+
+	var total float64
+	for _, row := range rows {
+		if col < len(row) {
+			hours, _ := strconv.ParseFloat(row[col], 64)
+			total += hours
+		}
+	}
+	return math.Round(total*100) / 100
+}
+
+// sheetTitle describes the sheet in the header of the rendered formats
+func sheetTitle(meta SheetMeta) string {
+	title := "Work Log"
+	if meta.Repo != "" {
+		title += " · " + meta.Repo
+	}
+	if r := MetaRange(meta); !r.IsZero() {
+		title += " · " + r.String()
+	}
+	return title
+}
+
+func writeXLSX(w io.Writer, records [][]string, meta SheetMeta) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Work Log"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"3C82F9"}},
+		Alignment: &excelize.Alignment{Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	hoursStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return err
+	}
+	totalStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, NumFmt: 2})
+	if err != nil {
+		return err
+	}
+
+	header := records[0]
+	hoursCol := slices.Index(header, "Hours")
+
+	for r, record := range records {
+		for c, value := range record {
+			cell, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return err
+			}
+
+			// hours are numbers so they can be summed in the spreadsheet
+			var v any = value
+			if r > 0 && c == hoursCol {
+				if hours, err := strconv.ParseFloat(value, 64); err == nil {
+					v = hours
+				}
+			}
+			if err := f.SetCellValue(sheet, cell, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	last, err := excelize.CoordinatesToCellName(len(header), 1)
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A1", last, headerStyle); err != nil {
+		return err
+	}
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	if err := f.AutoFilter(sheet, "A1:"+last, nil); err != nil {
+		return err
+	}
+	for c, name := range header {
+		col, err := excelize.ColumnNumberToName(c + 1)
+		if err != nil {
+			return err
+		}
+		width := float64(max(len(name), 10) + 2)
+		if name == "Description" {
+			width = 60
+		}
+		if err := f.SetColWidth(sheet, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	if hoursCol >= 0 && len(records) > 1 {
+		col, err := excelize.ColumnNumberToName(hoursCol + 1)
+		if err != nil {
+			return err
+		}
+		totalRow := len(records) + 1
+
+		if err := f.SetCellStyle(sheet, fmt.Sprintf("%s2", col), fmt.Sprintf("%s%d", col, len(records)), hoursStyle); err != nil {
+			return err
+		}
+		if err := f.SetCellValue(sheet, fmt.Sprintf("A%d", totalRow), "Total"); err != nil {
+			return err
+		}
+		totalCell := fmt.Sprintf("%s%d", col, totalRow)
+		// cached so viewers that don't recalculate still show the total
+		if err := f.SetCellValue(sheet, totalCell, TotalHours(header, records[1:])); err != nil {
+			return err
+		}
+		if err := f.SetCellFormula(sheet, totalCell, fmt.Sprintf("SUM(%s2:%s%d)", col, col, len(records))); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, fmt.Sprintf("A%d", totalRow), totalCell, totalStyle); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SetDocProps(&excelize.DocProperties{Title: sheetTitle(meta), Creator: meta.GeneratedBy}); err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
+
+func writeMarkdown(w io.Writer, records [][]string, meta SheetMeta) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+	row := func(values []string) string {
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = escape.Replace(v)
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", sheetTitle(meta))
+	if meta.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", meta.Description)
+	}
+
+	b.WriteString(row(records[0]))
+	b.WriteString("|" + strings.Repeat(" --- |", len(records[0])) + "\n")
+	for _, record := range records[1:] {
+		b.WriteString(row(record))
+	}
+	fmt.Fprintf(&b, "\n**Total hours:** %.2f\n", TotalHours(records[0], records[1:]))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlSheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: Arial, sans-serif; color: #333333; margin: 24px; }
+  table { border-collapse: collapse; width: 100%; font-size: 14px; }
+  th { background-color: #3C82F9; color: #ffffff; text-align: left; }
+  th, td { border: 1px solid #dddddd; padding: 6px 10px; }
+  tr:nth-child(even) td { background-color: #f7f9fc; }
+  tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<table>
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+<tfoot><tr><td colspan="{{len .Header}}">Total hours: {{printf "%.2f" .TotalHours}}</td></tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, records [][]string, meta SheetMeta) error {
+	return htmlSheetTemplate.Execute(w, map[string]any{
+		"Title":       sheetTitle(meta),
+		"Description": meta.Description,
+		"Header":      records[0],
+		"Rows":        records[1:],
+		"TotalHours":  TotalHours(records[0], records[1:]),
+	})
+}
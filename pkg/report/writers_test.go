@@ -0,0 +1,142 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	testSheetMeta = SheetMeta{Repo: "sheet-happens", GeneratedBy: "dev@example.com", Description: "April <hours>"}
+	testSheet     = [][]string{
+		{"Date", "Author Name", "Description", "Hours"},
+		{"2025-04-01 09:00:00", "Dev", "login | logout", "1.5"},
+		{"2025-04-01 11:00:00", "Dev", "fix\r\nsecond line", "0.25"},
+		{"2025-04-01 14:00:00", "Dev", "<script>alert(1)</script> & more", "2"},
+	}
+)
+
+// writeTestSheet renders testSheet in `format`
+func writeTestSheet(t *testing.T, format string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WriteSheet(&buf, format, testSheet, testSheetMeta); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteJSON(t *testing.T) {
+	var sheet SheetJSON
+	if err := json.Unmarshal(writeTestSheet(t, FormatJSON), &sheet); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sheet.Header, testSheet[0]) || !reflect.DeepEqual(sheet.Rows, testSheet[1:]) {
+		t.Errorf("got header %q and rows %q, want the sheet", sheet.Header, sheet.Rows)
+	}
+	if sheet.TotalHours != 3.75 {
+		t.Errorf("total hours = %v, want 3.75", sheet.TotalHours)
+	}
+	if sheet.Meta == nil || sheet.Meta.Repo != testSheetMeta.Repo {
+		t.Errorf("meta = %+v, want the sheet's", sheet.Meta)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	f, err := excelize.OpenReader(bytes.NewReader(writeTestSheet(t, FormatXLSX)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	const sheet = "Work Log"
+	cell := func(name string) string {
+		t.Helper()
+		value, err := f.GetCellValue(sheet, name, excelize.Options{RawCellValue: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	if got, _ := f.GetCellValue(sheet, "C2"); got != "login | logout" {
+		t.Errorf("C2 = %q", got)
+	}
+	// raw values of text cells are indexes into the shared strings
+	if got := cell("D3"); got != "0.25" {
+		t.Errorf("hours cell D3 = %q, want the number 0.25", got)
+	}
+
+	// the totals row is right under the last row
+	if got, _ := f.GetCellValue(sheet, "A5"); got != "Total" {
+		t.Errorf("A5 = %q, want Total", got)
+	}
+	if got := cell("D5"); got != "3.75" {
+		t.Errorf("cached total = %q, want 3.75", got)
+	}
+	if formula, err := f.GetCellFormula(sheet, "D5"); err != nil || formula != "SUM(D2:D4)" {
+		t.Errorf("total formula = %q, %v, want SUM(D2:D4)", formula, err)
+	}
+
+	props, err := f.GetDocProps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Title != "Work Log · sheet-happens" || props.Creator != "dev@example.com" {
+		t.Errorf("doc props = %q by %q", props.Title, props.Creator)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	got := string(writeTestSheet(t, FormatMarkdown))
+
+	want := []string{
+		"# Work Log · sheet-happens",
+		"| Date | Author Name | Description | Hours |",
+		"| --- | --- | --- | --- |",
+		`| 2025-04-01 09:00:00 | Dev | login \| logout | 1.5 |`,
+		"| 2025-04-01 11:00:00 | Dev | fix second line | 0.25 |",
+		"**Total hours:** 3.75",
+	}
+	for _, line := range want {
+		if !strings.Contains("\n"+got+"\n", "\n"+line+"\n") {
+			t.Errorf("missing line %q in\n%s", line, got)
+		}
+	}
+	// one line per row, a cell can't break the table
+	if rows := strings.Count(got, "\n| 2025-"); rows != 3 {
+		t.Errorf("got %d rows, want 3 in\n%s", rows, got)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	got := string(writeTestSheet(t, FormatHTML))
+
+	for _, unescaped := range []string{"<script>", "<hours>"} {
+		if strings.Contains(got, unescaped) {
+			t.Errorf("%s left unescaped in\n%s", unescaped, got)
+		}
+	}
+	for _, want := range []string{
+		"<td>&lt;script&gt;alert(1)&lt;/script&gt; &amp; more</td>",
+		"<p>April &lt;hours&gt;</p>",
+		"<title>Work Log · sheet-happens</title>",
+		`<td colspan="4">Total hours: 3.75</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteSheetUnknownFormat(t *testing.T) {
+	if err := WriteSheet(&bytes.Buffer{}, "pdf", testSheet, testSheetMeta); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("got %v, want an unknown format error", err)
+	}
+}
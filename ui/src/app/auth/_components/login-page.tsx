@@ -13,6 +13,7 @@ import {
 import { Input } from "@/components/ui/input";
 import { zodResolver } from "@hookform/resolvers/zod";
 import { useRouter } from "next/navigation";
+import { useState } from "react";
 import { useForm } from "react-hook-form";
 
 import LogoComponent from "../../../../public/logo";
@@ -26,50 +27,78 @@ const formSchema = z.object({
     .refine((email) => email.endsWith("@webpoint.io"), {
       message: "Email must be a webpoint.io address",
     }),
-  password: z.string(),
+  code: z.string(),
 });
 
 type FormValues = z.infer<typeof formSchema>;
 
 const LoginComponent = () => {
   const router = useRouter();
+  const [codeSent, setCodeSent] = useState(false);
 
   const form = useForm<FormValues>({
     resolver: zodResolver(formSchema),
     defaultValues: {
       email: "",
-      password: "",
+      code: "",
     },
   });
 
-  async function login(values: FormValues) {
+  // First step: the backend emails a one-time code to the address
+  async function requestCode(values: FormValues) {
     try {
       const response = await fetch(`${env.NEXT_PUBLIC_BACKEND_URL}/login`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
         },
-        body: JSON.stringify(values),
+        body: JSON.stringify({ email: values.email }),
       });
 
       if (!response.ok) {
-        // Handle the error if the response is not OK
-        throw new Error("Login failed");
+        throw new Error("Could not send login code");
+      }
+
+      setCodeSent(true);
+    } catch (error) {
+      console.error("Login error:", error);
+    }
+  }
+
+  // Second step: exchange the code for the HttpOnly session cookie
+  async function verifyCode(values: FormValues) {
+    try {
+      const response = await fetch(
+        `${env.NEXT_PUBLIC_BACKEND_URL}/login/verify`,
+        {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          credentials: "include",
+          body: JSON.stringify({ email: values.email, code: values.code }),
+        },
+      );
+
+      if (!response.ok) {
+        form.setError("code", { message: "Invalid or expired code" });
+        return;
       }
 
       const data = await response.json();
-      console.log("Login successful:", data);
-      localStorage.setItem("token", data.payload.name); // Store token in local storage
+      localStorage.setItem("name", data.payload.name);
       router.push("/dashboard"); // Redirect to dashboard after successful login
     } catch (error) {
       console.error("Login error:", error);
-      // Optionally display error messages to the user
     }
   }
 
   function onSubmit(values: FormValues) {
-    console.log(values);
-    login(values);
+    if (codeSent) {
+      verifyCode(values);
+    } else {
+      requestCode(values);
+    }
   }
 
   return (
@@ -108,48 +137,32 @@ const LoginComponent = () => {
                   )}
                 />
 
-                <FormField
-                  control={form.control}
-                  name="password"
-                  render={({ field }) => (
-                    <FormItem>
-                      <FormLabel>Password</FormLabel>
-                      <FormControl>
-                        <Input
-                          type="password"
-                          placeholder="Enter your password"
-                          {...field}
-                        />
-                      </FormControl>
-                      <FormMessage />
-                    </FormItem>
-                  )}
-                />
-
-                <div className="flex justify-between items-center">
-                  <div className="flex items-center space-x-2">
-                    <input
-                      type="checkbox"
-                      id="remember"
-                      className="rounded border-gray-300"
-                    />
-                    <label
-                      htmlFor="remember"
-                      className="text-sm text-gray-500 py-4"
-                    >
-                      Remember for 30 days
-                    </label>
-                  </div>
-                  <a href="#" className="text-sm text-blue-600 hover:underline">
-                    Forgot password?
-                  </a>
-                </div>
+                {codeSent && (
+                  <FormField
+                    control={form.control}
+                    name="code"
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>Login code</FormLabel>
+                        <FormControl>
+                          <Input
+                            inputMode="numeric"
+                            autoComplete="one-time-code"
+                            placeholder="Enter the code we emailed you"
+                            {...field}
+                          />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                )}
 
                 <Button
                   type="submit"
                   className="w-full bg-blue-600 hover:bg-blue-700"
                 >
-                  Sign in
+                  {codeSent ? "Sign in" : "Email me a code"}
                 </Button>
               </form>
             </Form>
@@ -32,16 +32,18 @@ export default function GetCsvPage({
   useEffect(() => {
     // Get the user name from localStorage when component mounts
     if (typeof window !== "undefined") {
-      const storedToken = localStorage.getItem("token");
-      if (storedToken) {
-        setUserName(storedToken);
+      const storedName = localStorage.getItem("name");
+      if (storedName) {
+        setUserName(storedName);
       }
     }
 
     async function fetchCsv() {
       try {
         // Use the dynamic ID from props in the API URL
-        const res = await fetch(`${env.NEXT_PUBLIC_BACKEND_URL}/csv/${id}`);
+        const res = await fetch(`${env.NEXT_PUBLIC_BACKEND_URL}/csv/${id}`, {
+          credentials: "include",
+        });
 
         if (!res.ok) {
           throw new Error(`HTTP error! Status: ${res.status}`);
@@ -144,6 +144,8 @@ const Recipient = ({ csvData = [] }: RecipientProps) => {
         `${env.NEXT_PUBLIC_BACKEND_URL}/csv?receiver=${emailList}`,
         {
           method: "POST",
+          // the session cookie set by /login/verify
+          credentials: "include",
 
           // Don't set Content-Type header, it will be set automatically with the correct boundary
           body: formData,